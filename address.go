@@ -0,0 +1,82 @@
+package contacts
+
+import "strings"
+
+// ParseFormattedAddress splits a formatted address into best-effort
+// components. It expects the street first, then the city, the region and
+// the postcode, and the country last, separated by newlines or, on a
+// single line, by commas, e.g.
+//
+//	1600 Amphitheatre Pkwy
+//	Mountain View, CA 94043
+//	USA
+//
+// Components it can't recognize are left empty. FormattedAddress is set to s.
+func ParseFormattedAddress(s string) GDStructuredPostalAddress {
+	ret := GDStructuredPostalAddress{FormattedAddress: s}
+
+	var parts []string
+	sep := "\n"
+	if !strings.Contains(strings.TrimSpace(s), "\n") {
+		sep = ","
+	}
+	for _, p := range strings.Split(s, sep) {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return ret
+	}
+
+	ret.Street, parts = parts[0], parts[1:]
+	if len(parts) > 1 && !strings.ContainsAny(parts[len(parts)-1], "0123456789") {
+		ret.Country, parts = parts[len(parts)-1], parts[:len(parts)-1]
+	}
+	if len(parts) == 0 {
+		return ret
+	}
+
+	// the rest reads as "City, Region PostCode".
+	rest := strings.Join(parts, ", ")
+	city, region, ok := strings.Cut(rest, ",")
+	if !ok {
+		city, region = "", rest
+	}
+	ret.City = strings.TrimSpace(city)
+	fields := strings.Fields(region)
+	if n := len(fields); n > 0 && strings.ContainsAny(fields[n-1], "0123456789") {
+		ret.PostCode, fields = fields[n-1], fields[:n-1]
+	}
+	ret.Region = strings.Join(fields, " ")
+	if ret.City == "" && ret.PostCode == "" {
+		// a lone part is more likely the city than the region.
+		ret.City, ret.Region = ret.Region, ""
+	}
+
+	return ret
+}
+
+// FillFromFormatted fills the empty components of a from its
+// FormattedAddress with ParseFormattedAddress. Components already set are
+// kept.
+func (a *GDStructuredPostalAddress) FillFromFormatted() {
+	if a.FormattedAddress == "" {
+		return
+	}
+	p := ParseFormattedAddress(a.FormattedAddress)
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&a.Street, p.Street},
+		{&a.City, p.City},
+		{&a.Region, p.Region},
+		{&a.PostCode, p.PostCode},
+		{&a.Country, p.Country},
+	} {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package contacts
+
+import "testing"
+
+func TestParseFormattedAddress(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		expect GDStructuredPostalAddress
+	}{
+		{"1600 Amphitheatre Pkwy\nMountain View, CA 94043\nUSA", GDStructuredPostalAddress{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", Region: "CA", PostCode: "94043", Country: "USA"}},
+		{"1600 Amphitheatre Pkwy, Mountain View, CA 94043", GDStructuredPostalAddress{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", Region: "CA", PostCode: "94043"}},
+		{"Longbourn\nMeryton", GDStructuredPostalAddress{Street: "Longbourn", City: "Meryton"}},
+	} {
+		tc.expect.FormattedAddress = tc.in
+		if got := ParseFormattedAddress(tc.in); got != tc.expect {
+			t.Errorf("%q: expect %+v, got %+v", tc.in, tc.expect, got)
+		}
+	}
+}
+
+func TestFillFromFormatted(t *testing.T) {
+	a := GDStructuredPostalAddress{FormattedAddress: "1600 Amphitheatre Pkwy\nMountain View, CA 94043\nUSA"}
+	a.FillFromFormatted()
+	if a.Street != "1600 Amphitheatre Pkwy" || a.City != "Mountain View" || a.Region != "CA" || a.PostCode != "94043" || a.Country != "USA" {
+		t.Fatalf("expect components filled, got %+v", a)
+	}
+
+	a = GDStructuredPostalAddress{City: "Palo Alto", FormattedAddress: "1600 Amphitheatre Pkwy\nMountain View, CA 94043"}
+	a.FillFromFormatted()
+	if a.City != "Palo Alto" || a.Street != "1600 Amphitheatre Pkwy" {
+		t.Fatalf("expect set components kept, got %+v", a)
+	}
+}
@@ -0,0 +1,330 @@
+package contacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// maxBatchOps is the number of operations the batch feed accepts per request.
+const maxBatchOps = 100
+
+// BatchOperation is the operation type of a batch entry.
+type BatchOperation string
+
+// Batch operation types.
+const (
+	BatchInsert BatchOperation = "insert"
+	BatchUpdate BatchOperation = "update"
+	BatchDelete BatchOperation = "delete"
+	BatchQuery  BatchOperation = "query"
+)
+
+// BatchOp is a single operation of a batch request.
+type BatchOp struct {
+	Type BatchOperation
+
+	// Contact is the payload of insert and update operations.
+	Contact *ContactKind
+
+	// ID is the contact id of update, delete and query operations.
+	// If it's empty, the id of Contact is used.
+	ID string
+
+	// Etag makes update and delete operations conditional.
+	// If it's empty, the etag of Contact is used, then '*'.
+	Etag string
+
+	// BatchID correlates the operation with its result.
+	// If it's empty, the index of the operation is used.
+	BatchID string
+
+	// Queries are the query parameters of a query operation, e.g.
+	// WithShowDeleted(true) to get the contact even if it's deleted.
+	Queries []func(url.Values)
+}
+
+// BatchResult is the outcome of a BatchOp reported by the server.
+type BatchResult struct {
+	BatchID string
+	Type    BatchOperation
+
+	// StatusCode and Reason are the HTTP-like status of the operation.
+	StatusCode int
+	Reason     string
+
+	// Contact is the saved version of inserted or updated contacts,
+	// or the contact of a query operation.
+	Contact *ContactKind
+}
+
+// Succeeded reports whether the server reported a 2xx status for the operation.
+func (r BatchResult) Succeeded() bool { return r.StatusCode/100 == 2 }
+
+// FailedBatchOps returns the operations of ops whose results failed, so
+// that they can be retried. results are the results of BatchContacts for ops.
+func FailedBatchOps(ops []BatchOp, results []BatchResult) []BatchOp {
+	var ret []BatchOp
+	for i, op := range ops {
+		if i >= len(results) || !results[i].Succeeded() {
+			ret = append(ret, op)
+		}
+	}
+	return ret
+}
+
+// batchEntry is a request entry of the batch feed.
+type batchEntry struct {
+	op      BatchOp
+	batchID string
+	id      string
+	opts    encodeOptions
+}
+
+// MarshalXML implements xml.Marshaler.
+func (b batchEntry) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	etag := b.op.Etag
+	if etag == "" && b.op.Contact != nil {
+		etag = b.op.Contact.etag
+	}
+	if etag == "" {
+		etag = "*"
+	}
+
+	switch b.op.Type {
+	case BatchInsert:
+		return b.op.Contact.marshalEntry(e, start, entryExtras{BatchID: b.batchID, BatchOperation: string(b.op.Type)}, b.opts)
+	case BatchUpdate:
+		return b.op.Contact.marshalEntry(e, start, entryExtras{ID: b.id, Etag: etag, BatchID: b.batchID, BatchOperation: string(b.op.Type)}, b.opts)
+	default:
+		if b.op.Type == BatchQuery {
+			// a query isn't conditional.
+			etag = ""
+		}
+		type encodeBatchEntry struct {
+			Etag      string         `xml:"gd:etag,attr,omitempty"`
+			ID        string         `xml:"id"`
+			BatchID   string         `xml:"batch:id"`
+			Operation batchOperation `xml:"batch:operation"`
+		}
+		start.Name = xml.Name{Space: "", Local: "entry"}
+		return e.EncodeElement(encodeBatchEntry{
+			Etag:      etag,
+			ID:        b.id,
+			BatchID:   b.batchID,
+			Operation: batchOperation{Type: string(b.op.Type)},
+		}, start)
+	}
+}
+
+// BatchContacts runs operations through the batch feed.
+// Operations over the limit of 100 per request are split into sequential requests.
+// The results are matched to the operations by batch:id, since the server
+// may report them in any order; the i-th result is the result of ops[i].
+// An operation the server reported no result for has a zero StatusCode.
+//
+// If a request fails, the results of the earlier requests, which the
+// server applied, are returned with the error; the operations from the
+// failed request on have no result. FailedBatchOps(ops, results) then
+// returns the operations that failed or weren't run.
+func (s *service) BatchContacts(ctx context.Context, ops []BatchOp) ([]BatchResult, error) {
+	entries := make([]batchEntry, 0, len(ops))
+	batchIDs := make(map[string]bool, len(ops))
+	for i, op := range ops {
+		switch op.Type {
+		case BatchInsert, BatchUpdate:
+			if op.Contact == nil {
+				return nil, fmt.Errorf("BatchContacts error: operation %d: %s without contact", i, op.Type)
+			}
+			validate := ContactKind.validateForUpdate
+			if op.Type == BatchInsert {
+				validate = ContactKind.validateForCreate
+			}
+			if err := validate(s.encode.apply(*op.Contact), s.propertyLimits); err != nil {
+				return nil, fmt.Errorf("BatchContacts error: operation %d: %w", i, err)
+			}
+		case BatchDelete, BatchQuery:
+		default:
+			return nil, fmt.Errorf("BatchContacts error: operation %d: unknown type %q", i, op.Type)
+		}
+
+		b := batchEntry{op: op, batchID: op.BatchID, opts: s.encode}
+		if b.batchID == "" {
+			b.batchID = strconv.Itoa(i)
+		}
+		if batchIDs[b.batchID] {
+			return nil, fmt.Errorf("BatchContacts error: operation %d: duplicate batch id %q", i, b.batchID)
+		}
+		batchIDs[b.batchID] = true
+		if op.Type != BatchInsert {
+			b.id = s.batchEntryID(op)
+			if b.id == "" {
+				return nil, fmt.Errorf("BatchContacts error: operation %d: %s without id", i, op.Type)
+			}
+		}
+		if op.Type == BatchQuery && len(op.Queries) > 0 {
+			params := url.Values{}
+			if err := applyQueries(params, op.Queries); err != nil {
+				return nil, fmt.Errorf("BatchContacts error: operation %d: %w", i, err)
+			}
+			b.id += "?" + params.Encode()
+		}
+		entries = append(entries, b)
+	}
+
+	return s.batchChunks(ctx, entries)
+}
+
+// batchChunks sends entries in sequential requests of at most maxBatchOps
+// entries, and returns their results in the order of entries. On error,
+// it returns the results of the requests before the failed one.
+func (s *service) batchChunks(ctx context.Context, entries []batchEntry) ([]BatchResult, error) {
+	ret := make([]BatchResult, 0, len(entries))
+	for len(entries) > 0 {
+		n := len(entries)
+		if n > maxBatchOps {
+			n = maxBatchOps
+		}
+		results, err := s.batch(ctx, entries[:n])
+		if err != nil {
+			return ret, err
+		}
+		byID := make(map[string]BatchResult, len(results))
+		for _, r := range results {
+			byID[r.BatchID] = r
+		}
+		for _, b := range entries[:n] {
+			r, ok := byID[b.batchID]
+			if !ok {
+				r = BatchResult{BatchID: b.batchID, Type: b.op.Type}
+			}
+			delete(byID, b.batchID)
+			ret = append(ret, r)
+		}
+		for id := range byID {
+			return ret, fmt.Errorf("BatchContacts error: unexpected batch id %q in the response", id)
+		}
+		entries = entries[n:]
+	}
+
+	return ret, nil
+}
+
+// batchEntryID returns the atom id of the contact an operation targets.
+func (s *service) batchEntryID(op BatchOp) string {
+	if op.ID != "" {
+		return fmt.Sprintf("%s/base/%s", s.endpoint, op.ID)
+	}
+	if op.Contact != nil {
+		return op.Contact.id
+	}
+	return ""
+}
+
+// batch sends a single batch request of at most maxBatchOps entries.
+func (s *service) batch(ctx context.Context, entries []batchEntry) ([]BatchResult, error) {
+	type encodeBatchFeed struct {
+		XMLName    xml.Name     `xml:"feed"`
+		Xmlns      string       `xml:"xmlns,attr"`
+		XmlnsGD    string       `xml:"xmlns:gd,attr"`
+		XmlnsBatch string       `xml:"xmlns:batch,attr"`
+		Entries    []batchEntry `xml:"entry"`
+	}
+
+	buf := &bytes.Buffer{}
+	enc := xml.NewEncoder(buf)
+	err := enc.Encode(encodeBatchFeed{
+		Xmlns:      nsAtom,
+		XmlnsGD:    nsGD,
+		XmlnsBatch: nsBatch,
+		Entries:    entries,
+	})
+	if err != nil {
+		defer enc.Close()
+		return nil, fmt.Errorf("BatchContacts error: could not encode xml payload: %w", err)
+	}
+	enc.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/full/batch", buf)
+	if err != nil {
+		return nil, fmt.Errorf("BatchContacts error: could not create a HTTP request: %w", err)
+	}
+
+	res, err := s.base.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("BatchContacts error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("BatchContacts error: %w", newAPIError(res))
+	}
+
+	ret := make([]BatchResult, 0, len(entries))
+	dec := xml.NewDecoder(res.Body)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("BatchContacts error: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Space != nsAtom || se.Name.Local != "entry" {
+			continue
+		}
+
+		r, err := decodeBatchResult(dec, se)
+		if err != nil {
+			return nil, fmt.Errorf("BatchContacts error: %w", err)
+		}
+		ret = append(ret, r)
+	}
+
+	return ret, nil
+}
+
+// decodeBatchResult decodes a response entry of the batch feed.
+func decodeBatchResult(d *xml.Decoder, start xml.StartElement) (BatchResult, error) {
+	type decodeBatchEntry struct {
+		BatchID   string `xml:"http://schemas.google.com/gdata/batch id"`
+		Operation struct {
+			Type string `xml:"type,attr"`
+		} `xml:"http://schemas.google.com/gdata/batch operation"`
+		Status struct {
+			Code   int    `xml:"code,attr"`
+			Reason string `xml:"reason,attr"`
+		} `xml:"http://schemas.google.com/gdata/batch status"`
+	}
+
+	toks, err := captureElement(d, start)
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	var o decodeBatchEntry
+	if err = decodeCaptured(toks, &o); err != nil {
+		return BatchResult{}, err
+	}
+	r := BatchResult{
+		BatchID:    o.BatchID,
+		Type:       BatchOperation(o.Operation.Type),
+		StatusCode: o.Status.Code,
+		Reason:     o.Status.Reason,
+	}
+
+	if r.StatusCode/100 == 2 && r.Type != BatchDelete {
+		var ct ContactKind
+		if err = decodeCaptured(toks, &ct); err != nil {
+			return BatchResult{}, err
+		}
+		r.Contact = &ct
+	}
+
+	return r, nil
+}
@@ -0,0 +1,259 @@
+package contacts
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// batchRequestFeed decodes the request body of the batch feed.
+type batchRequestFeed struct {
+	Entries []struct {
+		Etag      string `xml:"http://schemas.google.com/g/2005 etag,attr"`
+		ID        string `xml:"http://www.w3.org/2005/Atom id"`
+		BatchID   string `xml:"http://schemas.google.com/gdata/batch id"`
+		Operation struct {
+			Type string `xml:"type,attr"`
+		} `xml:"http://schemas.google.com/gdata/batch operation"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+// writeBatchResponse writes a batch response feed reporting code for every entry.
+func writeBatchResponse(w http.ResponseWriter, f batchRequestFeed, code int) {
+	fmt.Fprint(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' xmlns:batch='http://schemas.google.com/gdata/batch'>`)
+	for _, e := range f.Entries {
+		fmt.Fprintf(w, `<entry>
+  <id>http://www.google.com/m8/feeds/contacts/example.com/base/c%s</id>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <batch:id>%s</batch:id>
+  <batch:operation type='%s'/>
+  <batch:status code='%d' reason='%s'/>
+</entry>`, e.BatchID, e.BatchID, e.Operation.Type, code, http.StatusText(code))
+	}
+	fmt.Fprint(w, `</feed>`)
+}
+
+func TestBatchContactsChunks(t *testing.T) {
+	var sizes []int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/m8/feeds/contacts/example.com/full/batch" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var f batchRequestFeed
+		if err := xml.NewDecoder(r.Body).Decode(&f); err != nil {
+			t.Errorf("decode batch request: %v", err)
+		}
+		sizes = append(sizes, len(f.Entries))
+		writeBatchResponse(w, f, http.StatusCreated)
+	}))
+
+	ops := make([]BatchOp, 250)
+	for i := range ops {
+		ops[i] = BatchOp{Type: BatchInsert, Contact: &ContactKind{Name: GDName{FullName: fmt.Sprintf("Contact %d", i)}}}
+	}
+
+	results, err := s.BatchContacts(context.Background(), ops)
+	if err != nil {
+		t.Fatalf("BatchContacts error: %v", err)
+	}
+	if fmt.Sprint(sizes) != "[100 100 50]" {
+		t.Fatalf("expect 3 batch requests of [100 100 50], got %v", sizes)
+	}
+	if len(results) != 250 {
+		t.Fatalf("expect 250 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if r.BatchID != fmt.Sprint(i) || r.StatusCode != http.StatusCreated || r.Type != BatchInsert {
+			t.Fatalf("result %d not match: %+v", i, r)
+		}
+		if r.Contact == nil || r.Contact.GetID() != "c"+r.BatchID {
+			t.Fatalf("result %d: expect saved contact", i)
+		}
+	}
+}
+
+func TestBatchContactsChunkFailure(t *testing.T) {
+	var requests int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var f batchRequestFeed
+		if err := xml.NewDecoder(r.Body).Decode(&f); err != nil {
+			t.Errorf("decode batch request: %v", err)
+		}
+		writeBatchResponse(w, f, http.StatusCreated)
+	}))
+
+	ops := make([]BatchOp, 250)
+	for i := range ops {
+		ops[i] = BatchOp{Type: BatchInsert, Contact: &ContactKind{Name: GDName{FullName: fmt.Sprintf("Contact %d", i)}}}
+	}
+
+	results, err := s.BatchContacts(context.Background(), ops)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expect the error of the 2nd request, got %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("expect no request after the failed one, got %d", requests)
+	}
+	if len(results) != 100 || results[99].BatchID != "99" || !results[99].Succeeded() {
+		t.Fatalf("expect the results of the 1st request, got %d", len(results))
+	}
+	if failed := FailedBatchOps(ops, results); len(failed) != 150 || failed[0].Contact != ops[100].Contact {
+		t.Fatalf("expect the operations from the failed request on, got %d", len(failed))
+	}
+}
+
+func TestBatchContactsDelete(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var f batchRequestFeed
+		if err := xml.NewDecoder(r.Body).Decode(&f); err != nil {
+			t.Errorf("decode batch request: %v", err)
+		}
+		for _, e := range f.Entries {
+			if e.Operation.Type != "delete" || e.Etag != "*" ||
+				!strings.HasSuffix(e.ID, "/m8/feeds/contacts/example.com/base/abc") {
+
+				t.Errorf("unexpected delete entry %+v", e)
+			}
+		}
+		writeBatchResponse(w, f, http.StatusOK)
+	}))
+
+	results, err := s.BatchContacts(context.Background(), []BatchOp{{Type: BatchDelete, ID: "abc"}})
+	if err != nil {
+		t.Fatalf("BatchContacts error: %v", err)
+	}
+	if len(results) != 1 || results[0].StatusCode != http.StatusOK || results[0].Contact != nil {
+		t.Fatalf("delete result not match: %+v", results)
+	}
+
+	if _, err = s.BatchContacts(context.Background(), []BatchOp{{Type: BatchDelete}}); err == nil {
+		t.Fatalf("expect error for delete without id")
+	}
+}
+
+func TestBatchContactsQueryDeleted(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var f batchRequestFeed
+		if err := xml.NewDecoder(r.Body).Decode(&f); err != nil {
+			t.Errorf("decode batch request: %v", err)
+		}
+		if len(f.Entries) != 1 || f.Entries[0].Etag != "" ||
+			f.Entries[0].ID != "https://www.google.com/m8/feeds/contacts/example.com/base/abc?showdeleted=true" {
+			t.Errorf("unexpected query entries %+v", f.Entries)
+		}
+		fmt.Fprint(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' xmlns:batch='http://schemas.google.com/gdata/batch'>
+<entry>
+  <id>http://www.google.com/m8/feeds/contacts/example.com/base/abc</id>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <gd:deleted/>
+  <batch:id>q</batch:id>
+  <batch:operation type='query'/>
+  <batch:status code='200' reason='Success'/>
+</entry>
+</feed>`)
+	}))
+
+	results, err := s.BatchContacts(context.Background(), []BatchOp{
+		{Type: BatchQuery, ID: "abc", BatchID: "q", Queries: []func(url.Values){WithShowDeleted(true)}},
+	})
+	if err != nil {
+		t.Fatalf("BatchContacts error: %v", err)
+	}
+	if len(results) != 1 || results[0].Type != BatchQuery || results[0].Contact == nil {
+		t.Fatalf("expect the queried contact, got %+v", results)
+	}
+	if !results[0].Contact.IsDeleted() {
+		t.Fatalf("expect the contact flagged deleted")
+	}
+}
+
+func TestBatchContactsInvalidQuery(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expect no request for an invalid query, got %s %s", r.Method, r.URL)
+	}))
+
+	_, err := s.BatchContacts(context.Background(), []BatchOp{
+		{Type: BatchQuery, ID: "abc", Queries: []func(url.Values){WithOrderBy("title")}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unsupported orderby") {
+		t.Fatalf("expect the orderby error, got %v", err)
+	}
+}
+
+func TestBatchContactsOutOfOrder(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var f batchRequestFeed
+		if err := xml.NewDecoder(r.Body).Decode(&f); err != nil {
+			t.Errorf("decode batch request: %v", err)
+		}
+		for i, j := 0, len(f.Entries)-1; i < j; i, j = i+1, j-1 {
+			f.Entries[i], f.Entries[j] = f.Entries[j], f.Entries[i]
+		}
+		writeBatchResponse(w, f, http.StatusOK)
+	}))
+
+	ops := []BatchOp{
+		{Type: BatchInsert, Contact: &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}}, BatchID: "liz"},
+		{Type: BatchDelete, ID: "abc", BatchID: "abc"},
+		{Type: BatchUpdate, ID: "def", Contact: &ContactKind{}, BatchID: "def"},
+	}
+	results, err := s.BatchContacts(context.Background(), ops)
+	if err != nil {
+		t.Fatalf("BatchContacts error: %v", err)
+	}
+	if len(results) != len(ops) {
+		t.Fatalf("expect %d results, got %d", len(ops), len(results))
+	}
+	for i, r := range results {
+		if r.BatchID != ops[i].BatchID || r.Type != ops[i].Type {
+			t.Fatalf("result %d not match operation %s: %+v", i, ops[i].BatchID, r)
+		}
+	}
+
+	ops[1].BatchID = "liz"
+	if _, err = s.BatchContacts(context.Background(), ops); err == nil {
+		t.Fatalf("expect error for a duplicate batch id")
+	}
+}
+
+func TestFailedBatchOps(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var f batchRequestFeed
+		if err := xml.NewDecoder(r.Body).Decode(&f); err != nil {
+			t.Errorf("decode batch request: %v", err)
+		}
+		fmt.Fprint(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' xmlns:batch='http://schemas.google.com/gdata/batch'>`)
+		for _, e := range f.Entries {
+			code := http.StatusOK
+			if e.BatchID == "def" {
+				code = http.StatusPreconditionFailed
+			}
+			fmt.Fprintf(w, `<entry><batch:id>%s</batch:id><batch:operation type='delete'/><batch:status code='%d'/></entry>`, e.BatchID, code)
+		}
+		fmt.Fprint(w, `</feed>`)
+	}))
+
+	ops := []BatchOp{
+		{Type: BatchDelete, ID: "abc", BatchID: "abc"},
+		{Type: BatchDelete, ID: "def", BatchID: "def"},
+	}
+	results, err := s.BatchContacts(context.Background(), ops)
+	if err != nil {
+		t.Fatalf("BatchContacts error: %v", err)
+	}
+	if !results[0].Succeeded() || results[1].Succeeded() {
+		t.Fatalf("expect abc succeeded and def failed, got %+v", results)
+	}
+	if failed := FailedBatchOps(ops, results); len(failed) != 1 || failed[0].ID != "def" {
+		t.Fatalf("expect def to retry, got %+v", failed)
+	}
+}
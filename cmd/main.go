@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -45,4 +46,16 @@ func main() {
 		fmt.Printf("%s %s %s\n", v.GetID(), v.Name.FullName, v.GetEtag())
 	}
 
+	// list again only if the feed changed since.
+	_, _, err = svc.ListContacts(ctx, "full", st.Etag, contacts.WithMaxResults(1000))
+	switch {
+	case errors.Is(err, contacts.ErrNotModified):
+		fmt.Println("the contacts are not modified")
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "could not list contacts: %v", err)
+		os.Exit(1)
+	default:
+		fmt.Println("the contacts are modified")
+	}
+
 }
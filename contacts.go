@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"html"
+	"io"
+	"iter"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,38 +29,173 @@ var ScopePeopleAPI = []string{
 // baseURL is the base endpoint of Domain Shared Contacts
 const baseURL = "https://www.google.com/m8/feeds"
 
+// gdataVersion is the GData protocol version the API requires.
+const gdataVersion = "3.0"
+
+// atomContentType is the default content type of the request bodies.
+const atomContentType = "application/atom+xml; charset=UTF-8"
+
+// maxRedirects is the number of redirects followed when no policy is set.
+const maxRedirects = 10
+
 // hTransport adds custom header that Domain Shared Contacts API need.
-type trapnsport struct{ base http.RoundTripper }
+type trapnsport struct {
+	base        http.RoundTripper
+	contentType string
+	retry       retryPolicy
+}
 
 func (rt *trapnsport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Set("GData-Version", "3.0")
+	// a RoundTripper must not modify the request it's given.
+	req = req.Clone(req.Context())
+	req.Header.Set("GData-Version", gdataVersion)
 	switch req.Method {
 	case http.MethodPost, http.MethodPut:
-		req.Header.Set("Content-Type", "application/atom+xml")
+		// requests with a body of their own type, such as photo uploads, keep it.
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", rt.contentType)
+		}
 	default:
 	}
 
-	return rt.base.RoundTrip(req)
+	base := rt.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return rt.retry.roundTrip(base, req)
+}
+
+// checkRedirect defers to policy; a nil policy follows up to maxRedirects
+// redirects. The GData headers need no care here: the transport sets them
+// on every request, redirected ones included.
+//
+// The Authorization header of the original request isn't sent to another
+// host, including the same host name on another port, which http.Client
+// would allow. Only a header set on the request itself can be dropped: a
+// transport below the service that authorizes each request, such as an
+// oauth2.Transport, adds it again to the redirected request.
+func checkRedirect(policy func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > 0 && req.URL.Host != via[0].URL.Host {
+			req.Header.Del("Authorization")
+		}
+		if policy != nil {
+			return policy(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
 }
 
 // Service talks to Domain Shared Contact API.
+// It's safe for concurrent use by multiple goroutines.
+// Responses with an unexpected HTTP status are reported as an *APIError.
 type Service interface {
-	// CreateContact creates a contact. Its return value is the saved version at server side.
+	// CreateContact creates a contact. Its return value is the saved version at server side,
+	// fetched from the Location of the response if the server sends no entry.
 	CreateContact(ctx context.Context, p *ContactKind) (*ContactKind, error)
 
-	// GetContact retreives a contact data. If etag is provided, it uses conditional retreives (returns nil, nil for HTTP 304 NOT MODIFIED)
+	// GetContact retreives a contact data. If etag is provided, it uses conditional retreives (returns nil, ErrNotModified for HTTP 304 NOT MODIFIED)
 	GetContact(ctx context.Context, id, projection, etag string) (*ContactKind, error)
 
-	// ListContacts retreives contacts. If the feed etag is provided, it uses conditional retreives (returns nil, nil for HTTP 304 NOT MODIFIED)
+	// GetContactRaw retrieves the entry XML of a contact as it was received.
+	// If etag is provided, it uses conditional retrieves (returns nil, ErrNotModified for HTTP 304 NOT MODIFIED)
+	GetContactRaw(ctx context.Context, id, projection, etag string) ([]byte, error)
+
+	// GetContactPhoto downloads the photo of a contact and returns its content type. It returns ErrNotFound if the contact has no photo.
+	GetContactPhoto(ctx context.Context, id string) (io.ReadCloser, string, error)
+
+	// GetContactPhotoIfNoneMatch downloads the photo of a contact unless it still has etag (returns ErrNotModified for HTTP 304 NOT MODIFIED)
+	GetContactPhotoIfNoneMatch(ctx context.Context, id, etag string) (io.ReadCloser, string, error)
+
+	// SetContactPhoto uploads the photo of a contact, overwriting the current one, and returns the etag of the new photo.
+	SetContactPhoto(ctx context.Context, id string, r io.Reader, contentType string) (string, error)
+
+	// SetContactPhotoIf uploads the photo of a contact if the current one has etag, otherwise it returns ErrConflict.
+	// If etag equals to '*', it overwrites the current version like SetContactPhoto.
+	SetContactPhotoIf(ctx context.Context, id, etag string, r io.Reader, contentType string) (string, error)
+
+	// DeleteContactPhoto deletes the photo of a contact. If etag is provided, only the version is met will be deleted.
+	// If etag is empty or equals to '*', it deletes the current version.
+	DeleteContactPhoto(ctx context.Context, id, etag string) error
+
+	// ExportPhotos downloads the photos of ids concurrently, keyed by id. If ctx is done, it returns the photos downloaded so far.
+	ExportPhotos(ctx context.Context, ids []string, concurrency int) (map[string][]byte, error)
+
+	// GetContacts retrieves the contacts of ids concurrently. It returns the found contacts and the errors keyed by id.
+	GetContacts(ctx context.Context, ids []string, projection string, concurrency int) (map[string]*ContactKind, map[string]error)
+
+	// ListContacts retreives contacts. If the feed etag is provided, it uses conditional retreives (returns nil, nil, ErrNotModified for HTTP 304 NOT MODIFIED)
 	ListContacts(ctx context.Context, projection, feedEtag string, queries ...func(url.Values)) ([]*ContactKind, *QueryStatus, error)
 
-	// UpdateContact changes a contact data. If etag is provided, only the version is met will run updates.
-	// If etag equals to '*', it overwrites the current version.
+	// IterateContacts yields contacts like ListContacts, fetching the pages as they are consumed.
+	IterateContacts(ctx context.Context, projection, feedEtag string, queries ...func(url.Values)) iter.Seq2[*ContactKind, error]
+
+	// StreamContacts calls fn for each contact of the feed as it's decoded, without holding the feed in memory.
+	StreamContacts(ctx context.Context, projection, feedEtag string, fn func(*ContactKind) error, queries ...func(url.Values)) (*QueryStatus, error)
+
+	// ListContactsInto retrieves contacts like ListContacts, reusing the capacity of dst. The result may share its backing array.
+	ListContactsInto(ctx context.Context, dst []*ContactKind, projection, feedEtag string, queries ...func(url.Values)) ([]*ContactKind, *QueryStatus, error)
+
+	// ExportNDJSON writes the contacts to w as newline-delimited JSON, one page at a time.
+	ExportNDJSON(ctx context.Context, w io.Writer, projection string, queries ...func(url.Values)) (int, error)
+
+	// ImportNDJSON saves the contacts read from r as newline-delimited JSON, creating or updating each.
+	ImportNDJSON(ctx context.Context, r io.Reader, concurrency int) (created, updated, failed int, err error)
+
+	// UpsertByEmail updates the existing contacts sharing a primary email with the given contacts, and creates the others.
+	UpsertByEmail(ctx context.Context, contacts []*ContactKind, concurrency int) (created, updated int, errs map[string]error)
+
+	// ListContactsMap retrieves contacts like ListContacts, keyed by their short ID.
+	ListContactsMap(ctx context.Context, projection, feedEtag string, queries ...func(url.Values)) (map[string]*ContactKind, *QueryStatus, error)
+
+	// UpdateContact changes a contact data. If etag is provided, only the version is met will run updates,
+	// otherwise it returns ErrConflict, as for an empty etag. If etag equals to '*', it overwrites the current version.
+	// With WithSkipNoopUpdates, it returns the current version without sending p if they are Equal.
 	UpdateContact(ctx context.Context, id, etag string, p *ContactKind) (*ContactKind, error)
 
-	// DeleteContact deletes a contact. If etag is provided, only the version is met will be deleted.
-	// If etag equals to '*', it overwrites the current version.
+	// TouchContact re-sends the current data of a contact to bump its updated time.
+	// If etag is provided, only the version is met will be touched.
+	TouchContact(ctx context.Context, id, etag string) (*ContactKind, error)
+
+	// DeleteContact deletes a contact. If etag is provided, only the version is met will be deleted,
+	// otherwise it returns ErrConflict. If etag equals to '*', it overwrites the current version.
 	DeleteContact(ctx context.Context, id, etag string) error
+
+	// Page retrieves a single page of contacts by its 1-based number, and the total number of contacts.
+	Page(ctx context.Context, projection string, pageNum, pageSize int, queries ...func(url.Values)) ([]*ContactKind, int, error)
+
+	// CreateGroup creates a group. Its return value is the saved version at server side.
+	CreateGroup(ctx context.Context, g *GroupKind) (*GroupKind, error)
+
+	// GetGroup retrieves a group. If etag is provided, it uses conditional retrieves (returns nil, ErrNotModified for HTTP 304 NOT MODIFIED)
+	GetGroup(ctx context.Context, id, etag string) (*GroupKind, error)
+
+	// ListGroups retrieves the groups of the domain.
+	ListGroups(ctx context.Context, queries ...func(url.Values)) ([]*GroupKind, error)
+
+	// UpdateGroup changes a group. If etag is provided, only the version is met will run updates,
+	// otherwise it returns ErrConflict, as for an empty etag. If etag equals to '*', it overwrites the current version.
+	UpdateGroup(ctx context.Context, id, etag string, g *GroupKind) (*GroupKind, error)
+
+	// DeleteGroup deletes a group. If etag is provided, only the version is met will be deleted,
+	// otherwise it returns ErrConflict, as for an empty etag. If etag equals to '*', it deletes the current version.
+	DeleteGroup(ctx context.Context, id, etag string) error
+
+	// ResolveGroups retrieves the groups of the contact's group memberships.
+	ResolveGroups(ctx context.Context, c *ContactKind) ([]GroupKind, error)
+
+	// CountContacts returns the total number of contacts of the queries, fetching a single entry.
+	CountContacts(ctx context.Context, projection string, queries ...func(url.Values)) (int, error)
+
+	// ListDirectoryProfiles retrieves the read-only profiles of the domain users.
+	ListDirectoryProfiles(ctx context.Context, queries ...func(url.Values)) ([]*ProfileKind, error)
+
+	// BatchContacts runs operations through the batch feed, 100 operations per request.
+	// If a request fails, the results of the earlier requests are returned with the error.
+	BatchContacts(ctx context.Context, ops []BatchOp) ([]BatchResult, error)
 }
 
 // In the Domain Shared Contacts API, several elements are slightly more restrictive than the contact kind.
@@ -71,21 +212,34 @@ type Service interface {
 
 // ContactKind is the contact API used, atom-xml based structure.
 // It represents a person's contact data. Such as address, name, email, etc...
+//
+// The slice and map fields are shared by copies of a ContactKind value, so
+// changing an element through one copy changes the others. Use Clone, or the
+// Emails, Phones, Addresses, IMs and ExtendedProperties accessors, to get
+// data that is safe to modify.
 type ContactKind struct {
 	Name                    GDName
 	Email                   []GDEmail
 	PhoneNumber             []GDPhoneNumber
 	StructuredPostalAddress []GDStructuredPostalAddress
 	IM                      []GDIM
+	Organization            []GDOrganization
 	ExtendedProperty        map[string]string
+	UserDefinedField        []GContactUserDefinedField
+	GroupMembership         []GroupMembershipInfo
+	Birthday                GContactBirthday
 
 	deleted   bool
+	draft     bool
 	editLink  string
 	photoLink string
 	selfLink  string
 	id        string
 	updated   time.Time
+	edited    time.Time
 	content   string
+	title     string
+	titleType string
 	etag      string
 }
 
@@ -96,66 +250,365 @@ func (c ContactKind) GetEditLink() string { return c.editLink }
 func (c ContactKind) GetPhotoLink() string { return c.photoLink }
 
 // GetID returns the ID of the contact entry.
+// It's the short form, the last path segment of the full ID, which is the
+// same for the base and full projections of a contact. Use GetFullID for
+// the complete ID URL.
 func (c ContactKind) GetID() string {
 	idx := strings.LastIndex(c.id, "/")
 	return c.id[idx+1:]
 }
 
+// GetFullID returns the complete ID URL of the contact entry,
+// e.g. http://www.google.com/m8/feeds/contacts/example.com/base/abc.
+func (c ContactKind) GetFullID() string { return c.id }
+
 // GetUpdated returns the last updated time of the contact entry.
 func (c ContactKind) GetUpdated() time.Time { return c.updated }
 
+// GetEdited returns the last edited time of the contact entry.
+// It's read from app:edited, falling back to the updated time when the
+// entry has no app:edited element.
+func (c ContactKind) GetEdited() time.Time {
+	if c.edited.IsZero() {
+		return c.updated
+	}
+	return c.edited
+}
+
 // GetEtag returns the etag of the contact entry.
 func (c ContactKind) GetEtag() string { return c.etag }
 
+// SetID sets the complete ID URL of the contact entry, as GetFullID returns it.
+//
+// It's meant to restore a contact rebuilt from a cache, e.g. from JSON,
+// for an update. The server owns the id, so a wrong one sends the update
+// to another contact or makes it fail.
+func (c *ContactKind) SetID(id string) { c.id = id }
+
+// SetEtag sets the etag of the contact entry, as GetEtag returns it.
+// Like SetID, it's meant to restore a cached contact, and a stale etag
+// makes a conditional update fail.
+func (c *ContactKind) SetEtag(etag string) { c.etag = etag }
+
+// SetEditLink sets the edit link of the contact entry, as GetEditLink
+// returns it. Like SetID, it's meant to restore a cached contact.
+func (c *ContactKind) SetEditLink(link string) { c.editLink = link }
+
+// SetPrimaryEmail makes the email of address the primary one, comparing
+// addresses case-insensitively, and demotes the previous primary emails.
+// It reports false, leaving c unchanged, if c has no such email.
+// The demoted emails are sent with primary="false" by a service created
+// WithExplicitPrimaryFalse.
+func (c *ContactKind) SetPrimaryEmail(address string) bool {
+	idx := -1
+	for i, m := range c.Email {
+		if strings.EqualFold(strings.TrimSpace(m.Address), strings.TrimSpace(address)) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+
+	for i := range c.Email {
+		switch {
+		case i == idx:
+			c.Email[i].Primary, c.Email[i].demoted = true, false
+		case c.Email[i].Primary:
+			c.Email[i].Primary, c.Email[i].demoted = false, true
+		}
+	}
+	return true
+}
+
+// ChangeSummary is the version of a contact entry, for audit logs.
+type ChangeSummary struct {
+	ID      string
+	Etag    string
+	Updated time.Time
+	Edited  time.Time
+}
+
+// ChangeSummary returns the version of the contact entry. The server keeps
+// no history, so callers that need one can log the summary of each version
+// they fetch. Edited falls back to Updated like GetEdited.
+func (c ContactKind) ChangeSummary() ChangeSummary {
+	return ChangeSummary{
+		ID:      c.GetID(),
+		Etag:    c.etag,
+		Updated: c.updated,
+		Edited:  c.GetEdited(),
+	}
+}
+
+// GetTitle returns the title of the contact entry as plain text.
+// The server sets it to the full name of the contact. A title of type
+// html has its tags removed and its character references unescaped.
+func (c ContactKind) GetTitle() string {
+	if c.titleType != "html" {
+		return c.title
+	}
+	return html.UnescapeString(stripTags(c.title))
+}
+
+// GetRawTitle returns the title of the contact entry as it was received,
+// and its type: "text", "html" or empty, which means text.
+func (c ContactKind) GetRawTitle() (title, typ string) { return c.title, c.titleType }
+
+// stripTags removes the HTML tags of s.
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsDeleted reports whether the entry is a deleted contact. Deleted
+// contacts are only returned by queries with WithShowDeleted.
+func (c ContactKind) IsDeleted() bool { return c.deleted }
+
+// IsDraft reports whether the entry is marked as a draft by
+// <app:control><app:draft>yes</app:draft></app:control>. Entries without
+// the element aren't drafts.
+func (c ContactKind) IsDraft() bool { return c.draft }
+
+// String returns a short summary of the contact for logs, e.g.
+// ContactKind{id=abc, name="Elizabeth Bennet", emails=2, phones=3, addresses=1, ims=0}.
+// Only the id and the name are shown; the emails, phone numbers, addresses
+// and IMs are counted, not printed.
+func (c ContactKind) String() string {
+	name := c.Name.FullName
+	if name == "" {
+		name = c.Name.composed()
+	}
+	return fmt.Sprintf("ContactKind{id=%s, name=%q, emails=%d, phones=%d, addresses=%d, ims=%d}",
+		c.GetID(), name, len(c.Email), len(c.PhoneNumber), len(c.StructuredPostalAddress), len(c.IM))
+}
+
+// NameOption sets an optional component of the name set by SetName.
+type NameOption func(*GDName)
+
+// WithAdditionalName sets the additional (middle) name.
+func WithAdditionalName(name string) NameOption {
+	return func(n *GDName) { n.AdditionalName = name }
+}
+
+// WithNamePrefix sets the honorific prefix, e.g. "Sir".
+func WithNamePrefix(prefix string) NameOption {
+	return func(n *GDName) { n.Prefix = prefix }
+}
+
+// WithNameSuffix sets the honorific suffix, e.g. "OG".
+func WithNameSuffix(suffix string) NameOption {
+	return func(n *GDName) { n.Suffix = suffix }
+}
+
+// SetName replaces the name with the given components, and sets the full
+// name composed from them, so that both forms agree.
+func (c *ContactKind) SetName(given, family string, opts ...NameOption) {
+	n := GDName{GivenName: given, FamilyName: family}
+	for _, opt := range opts {
+		opt(&n)
+	}
+	n.Compose()
+	c.Name = n
+}
+
+// Emails returns a copy of the email addresses.
+func (c ContactKind) Emails() []GDEmail { return cloneSlice(c.Email) }
+
+// Phones returns a copy of the phone numbers.
+func (c ContactKind) Phones() []GDPhoneNumber { return cloneSlice(c.PhoneNumber) }
+
+// Addresses returns a copy of the postal addresses.
+func (c ContactKind) Addresses() []GDStructuredPostalAddress {
+	return cloneSlice(c.StructuredPostalAddress)
+}
+
+// EmailAddresses returns the email addresses as plain strings, trimmed.
+func (c ContactKind) EmailAddresses() []string {
+	ret := make([]string, 0, len(c.Email))
+	for _, m := range c.Email {
+		ret = append(ret, strings.TrimSpace(m.Address))
+	}
+	return ret
+}
+
+// PhoneNumbers returns the dial numbers of the phone numbers as plain
+// strings, trimmed of the surrounding white space of the element text.
+func (c ContactKind) PhoneNumbers() []string {
+	ret := make([]string, 0, len(c.PhoneNumber))
+	for _, n := range c.PhoneNumber {
+		ret = append(ret, strings.TrimSpace(n.DialNumber))
+	}
+	return ret
+}
+
+// DisplayEmail returns the primary email for display, as
+// "DisplayName <address>" if it has a display name, else the address.
+// Without a primary email, it's the first email. It's empty without emails.
+func (c ContactKind) DisplayEmail() string {
+	if len(c.Email) == 0 {
+		return ""
+	}
+	m := c.Email[0]
+	for _, e := range c.Email {
+		if e.Primary {
+			m = e
+			break
+		}
+	}
+
+	address := strings.TrimSpace(m.Address)
+	if name := strings.TrimSpace(m.DisplayName); name != "" {
+		return name + " <" + address + ">"
+	}
+	return address
+}
+
+// IMs returns a copy of the instant message accounts.
+func (c ContactKind) IMs() []GDIM { return cloneSlice(c.IM) }
+
+// ExtendedProperties returns a copy of the extended properties.
+func (c ContactKind) ExtendedProperties() map[string]string { return cloneMap(c.ExtendedProperty) }
+
 // Clone clones the contact.
+// Every slice and map field is copied, so the clone shares no data with c.
+// A new slice or map field must be copied here too; TestCloneCopiesAllFields
+// fails when one isn't.
 func (c ContactKind) Clone() ContactKind {
-	ret := ContactKind{
-		Name:                    c.Name,
-		Email:                   make([]GDEmail, len(c.Email)),
-		PhoneNumber:             make([]GDPhoneNumber, len(c.PhoneNumber)),
-		StructuredPostalAddress: make([]GDStructuredPostalAddress, len(c.StructuredPostalAddress)),
-		IM:                      make([]GDIM, 0, len(c.IM)),
-		ExtendedProperty:        make(map[string]string),
-		deleted:                 c.deleted,
-		editLink:                c.editLink,
-		photoLink:               c.photoLink,
-		selfLink:                c.selfLink,
-		id:                      c.id,
-		updated:                 c.updated,
-		content:                 c.content,
-		etag:                    c.etag,
-	}
-	for _, v := range c.Email {
-		ret.Email = append(ret.Email, v)
-	}
-	for _, v := range c.PhoneNumber {
-		ret.PhoneNumber = append(ret.PhoneNumber, v)
-	}
-	for _, v := range c.StructuredPostalAddress {
-		ret.StructuredPostalAddress = append(ret.StructuredPostalAddress, v)
-	}
-	for _, v := range c.IM {
-		ret.IM = append(ret.IM, v)
-	}
-	for k, v := range c.ExtendedProperty {
-		ret.ExtendedProperty[k] = v
+	ret := c
+	ret.Email = cloneSlice(c.Email)
+	ret.PhoneNumber = cloneSlice(c.PhoneNumber)
+	ret.StructuredPostalAddress = cloneSlice(c.StructuredPostalAddress)
+	ret.IM = cloneSlice(c.IM)
+	ret.Organization = cloneSlice(c.Organization)
+	ret.ExtendedProperty = cloneMap(c.ExtendedProperty)
+	ret.UserDefinedField = cloneSlice(c.UserDefinedField)
+	ret.GroupMembership = cloneSlice(c.GroupMembership)
+	return ret
+}
+
+// cloneSlice returns a copy of s with its own backing array. The elements
+// are copied shallowly, which suffices for the element types of ContactKind
+// as they hold no slices, maps or pointers. A nil s stays nil.
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
 	}
+	return append(make([]T, 0, len(s)), s...)
+}
 
+// cloneMap returns a copy of m. The copy is never nil, so that it can be
+// written to.
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	ret := make(map[K]V, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
 	return ret
 }
 
+// CloneData clones the contact data without the server metadata.
+// The result has no id, etag, links or timestamps, so it suits a template
+// for creating new contacts. Use Clone to keep the metadata.
+func (c ContactKind) CloneData() ContactKind {
+	full := c.Clone()
+	return ContactKind{
+		Name:                    full.Name,
+		Email:                   full.Email,
+		PhoneNumber:             full.PhoneNumber,
+		StructuredPostalAddress: full.StructuredPostalAddress,
+		IM:                      full.IM,
+		Organization:            full.Organization,
+		ExtendedProperty:        full.ExtendedProperty,
+		UserDefinedField:        full.UserDefinedField,
+		GroupMembership:         full.GroupMembership,
+		Birthday:                full.Birthday,
+		content:                 full.content,
+	}
+}
+
 var endpointBaseURL = "https://www.google.com/m8/feeds/contacts/%s"
 
 type service struct {
 	base       *http.Client
+	domain     string
 	endpoint   string
 	projection string
+
+	checkRedirect  func(*http.Request, []*http.Request) error
+	transportOpts  []func(*http.Transport)
+	encode         encodeOptions
+	filter         func(*ContactKind) bool
+	lenient        bool
+	contentType    string
+	retry          retryPolicy
+	partialResults bool
+	propertyLimits propertyLimits
+	skipNoop       bool
+	linkHeader     bool
+	logger         *log.Logger
 }
 
 // NewService returns a Service that manipulate Domain Shread Contact API.
-func NewService(client *http.Client, domain, defaultProjection string) (Service, error) {
-	client.Transport = &trapnsport{base: client.Transport}
-	return &service{client, fmt.Sprintf(endpointBaseURL, domain), setDefaultProjection(defaultProjection)}, nil
+// The service works on a copy of client; client itself isn't modified.
+func NewService(client *http.Client, domain, defaultProjection string, opts ...ServiceOption) (Service, error) {
+	s := &service{
+		domain:         domain,
+		endpoint:       fmt.Sprintf(endpointBaseURL, domain),
+		projection:     setDefaultProjection(defaultProjection),
+		checkRedirect:  client.CheckRedirect,
+		contentType:    atomContentType,
+		propertyLimits: defaultPropertyLimits,
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, fmt.Errorf("NewService error: %w", err)
+		}
+	}
+
+	base, err := configureTransport(client.Transport, s.transportOpts)
+	if err != nil {
+		return nil, fmt.Errorf("NewService error: %w", err)
+	}
+	c := *client
+	c.Transport = &trapnsport{base: base, contentType: s.contentType, retry: s.retry}
+	c.CheckRedirect = checkRedirect(s.checkRedirect)
+	s.base = &c
+	return s, nil
+}
+
+// configureTransport applies opts to a clone of base.
+// Without opts, base is returned as is.
+func configureTransport(base http.RoundTripper, opts []func(*http.Transport)) (http.RoundTripper, error) {
+	if len(opts) == 0 {
+		return base, nil
+	}
+
+	var t *http.Transport
+	switch b := base.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = b.Clone()
+	default:
+		return nil, fmt.Errorf("transport options need a nil or *http.Transport client transport, got %T", base)
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t, nil
 }
 
 func setDefaultProjection(p string) string {
@@ -176,9 +629,14 @@ func (s service) getPojection(p string) string {
 }
 
 func (s *service) CreateContact(ctx context.Context, p *ContactKind) (*ContactKind, error) {
+	// validate what is sent, e.g. with the primary phone fixed.
+	if err := s.encode.apply(*p).validateForCreate(s.propertyLimits); err != nil {
+		return nil, fmt.Errorf("CreateContact error: %w", err)
+	}
+
 	buf := &bytes.Buffer{}
 	e := xml.NewEncoder(buf)
-	err := e.Encode(p)
+	err := e.Encode(payload{contact: p, opts: s.encode})
 	if err != nil {
 		defer e.Close()
 		return nil, err
@@ -201,28 +659,86 @@ func (s *service) CreateContact(ctx context.Context, p *ContactKind) (*ContactKi
 		defer res.Body.Close()
 		var ct ContactKind
 		err = d.Decode(&ct)
+		if err == io.EOF {
+			// no entry in the body, the created contact is at Location.
+			loc, err := res.Location()
+			if err != nil {
+				return nil, fmt.Errorf("CreateContact error: no entry nor Location in the response: %w", err)
+			}
+			return s.getCreated(ctx, loc.String())
+		}
 		if err != nil {
 			return nil, err
 		}
 		return &ct, nil
-	case http.StatusConflict:
-		return nil, fmt.Errorf("CreateContact error: version conflict")
-	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
-		return nil, fmt.Errorf("CreateContact error: %s", res.Status)
 	default:
-		return nil, fmt.Errorf("CreateContact error: unknown with %s", res.Status)
+		defer res.Body.Close()
+		return nil, fmt.Errorf("CreateContact error: %w", newAPIError(res))
 	}
 
 }
 
+// getCreated retrieves the contact created at u, the Location of a
+// response without the entry.
+func (s *service) getCreated(ctx context.Context, u string) (*ContactKind, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("CreateContact error: could not create a HTTP request: %w", err)
+	}
+	res, err := s.base.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("CreateContact error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CreateContact error: %w", newAPIError(res))
+	}
+
+	var ct ContactKind
+	if err = xml.NewDecoder(res.Body).Decode(&ct); err != nil {
+		return nil, fmt.Errorf("CreateContact error: %w", err)
+	}
+	return &ct, nil
+}
+
 func (s *service) GetContact(ctx context.Context, id string, projection string, etag string) (*ContactKind, error) {
 	return s.getContact(ctx, id, projection, etag, "could not get a contact from GetContact")
 }
 
 func (s *service) getContact(ctx context.Context, id string, projection string, etag string, errPrefix string) (*ContactKind, error) {
+	var contact ContactKind
+	found, err := s.getEntry(ctx, id, projection, etag, errPrefix, &contact)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: %w", errPrefix, ErrNotModified)
+	}
+
+	return &contact, nil
+}
+
+func (s *service) GetContactRaw(ctx context.Context, id string, projection string, etag string) ([]byte, error) {
+	const errPrefix = "could not get a contact from GetContactRaw"
+	var raw []byte
+	found, err := s.getEntry(ctx, id, projection, etag, errPrefix, &raw)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: %w", errPrefix, ErrNotModified)
+	}
+
+	return raw, nil
+}
+
+// getEntry retrieves the entry of id and decodes it into v.
+// If v is a *[]byte, it gets the entry XML verbatim instead.
+// It reports false without an error for HTTP 304 NOT MODIFIED.
+func (s *service) getEntry(ctx context.Context, id string, projection string, etag string, errPrefix string, v interface{}) (bool, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s/%s", s.endpoint, s.getPojection(projection), id), nil)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+		return false, fmt.Errorf("%s: %w", errPrefix, err)
 	}
 	if etag != "" && etag != "*" {
 		req.Header.Set("If-None-Match", etag)
@@ -230,125 +746,491 @@ func (s *service) getContact(ctx context.Context, id string, projection string,
 
 	res, err := s.base.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+		return false, fmt.Errorf("%s: %w", errPrefix, err)
 	}
+	defer res.Body.Close()
 
-	if res.StatusCode == http.StatusNotModified {
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
 		// use empty value as a signal
 		// this obviously is not the best way, but let's ues it now.
-		return nil, nil
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: %w", errPrefix, newAPIError(res))
+	}
+
+	if raw, ok := v.(*[]byte); ok {
+		if *raw, err = io.ReadAll(res.Body); err != nil {
+			return false, fmt.Errorf("%s: %w", errPrefix, err)
+		}
+		return true, nil
 	}
 
 	dec := xml.NewDecoder(res.Body)
-	defer res.Body.Close()
-	var contact ContactKind
-	err = dec.Decode(&contact)
-	if err != nil {
-		return nil, err
+	if err = dec.Decode(v); err != nil {
+		return false, err
 	}
 
-	return &contact, nil
+	return true, nil
+}
+
+// GetContacts retrieves the contacts of ids, running at most concurrency requests at once.
+// It returns the found contacts and the errors keyed by id. Ids not fetched
+// before ctx is done get the context error.
+func (s *service) GetContacts(ctx context.Context, ids []string, projection string, concurrency int) (map[string]*ContactKind, map[string]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		found = make(map[string]*ContactKind, len(ids))
+		errs  = make(map[string]error)
+		sem   = make(chan struct{}, concurrency)
+	)
+	for _, id := range ids {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs[id] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+			c, err := s.getContact(ctx, id, projection, "", "GetContacts error")
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[id] = err
+				return
+			}
+			found[id] = c
+		}(id)
+	}
+	wg.Wait()
+
+	return found, errs
 }
 
 // QueryStatus stores the querying state of the feed.
 type QueryStatus struct {
 	Updated time.Time
 	Etag    string
+
+	// FeedTitle is the title of the feed, e.g. "Domain Shared Contacts for example.com".
+	FeedTitle string
 }
 
 // By default, the entries in a feed aren't ordered.
 func (s *service) ListContacts(ctx context.Context, projection, etag string, queries ...func(url.Values)) ([]*ContactKind, *QueryStatus, error) {
-	params := url.Values{}
-	var u string
-	if len(queries) > 0 {
-		// add strict
-		withStrict()(params)
-		for _, q := range queries {
-			q(params)
+	return s.listContactsInto(ctx, "ListContacts", nil, projection, etag, queries)
+}
+
+// errStopIteration stops listPages when the consumer of IterateContacts breaks.
+var errStopIteration = errors.New("contacts: iteration stopped")
+
+// IterateContacts yields the contacts of the feed one at a time, like
+// ListContacts returns them. A page is fetched only once the contacts of
+// the previous one are consumed, so breaking out of the range stops
+// fetching. An error is yielded with a nil contact, and ends the iteration.
+func (s *service) IterateContacts(ctx context.Context, projection, etag string, queries ...func(url.Values)) iter.Seq2[*ContactKind, error] {
+	return func(yield func(*ContactKind, error) bool) {
+		_, err := s.listPages(ctx, "IterateContacts", projection, etag, queries, func(page []*ContactKind) error {
+			for _, c := range page {
+				if !yield(c, nil) {
+					return errStopIteration
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield(nil, err)
 		}
+	}
+}
 
-		u = fmt.Sprintf("%s/%s?%s", s.endpoint, s.getPojection(projection), params.Encode())
-	} else {
-		u = fmt.Sprintf("%s/%s", s.endpoint, s.getPojection(projection))
+// ListContactsInto retrieves contacts like ListContacts, appending them to
+// dst[:0] to reuse its capacity.
+//
+// The returned slice shares the backing array of dst if it's large enough,
+// so the contacts previously in dst are overwritten, even when an error is
+// returned. The caller shouldn't use dst after the call, only the result.
+// The contacts themselves are newly allocated.
+func (s *service) ListContactsInto(ctx context.Context, dst []*ContactKind, projection, etag string, queries ...func(url.Values)) ([]*ContactKind, *QueryStatus, error) {
+	return s.listContactsInto(ctx, "ListContactsInto", dst, projection, etag, queries)
+}
+
+func (s *service) listContactsInto(ctx context.Context, method string, dst []*ContactKind, projection, etag string, queries []func(url.Values)) ([]*ContactKind, *QueryStatus, error) {
+	ret := dst[:0]
+	if ret == nil {
+		ret = make([]*ContactKind, 0, 20)
 	}
+	st, err := s.listPages(ctx, method, projection, etag, queries, func(page []*ContactKind) error {
+		ret = append(ret, page...)
+		return nil
+	})
+	if err != nil {
+		var pe *PageError
+		if s.partialResults && errors.As(err, &pe) {
+			return ret, nil, err
+		}
+		return nil, nil, err
+	}
+
+	return ret, st, nil
+}
 
+// listPages retrieves the feed page by page, following the next links, and
+// calls fn with the contacts of each page. It stops at the first error of fn.
+// method prefixes the returned errors.
+func (s *service) listPages(ctx context.Context, method, projection, etag string, queries []func(url.Values), fn func([]*ContactKind) error) (*QueryStatus, error) {
+	u, err := s.listURL(projection, queries)
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("ListContacts error: could not create a HTTP request: %w", err)
+		return nil, fmt.Errorf("%s error: could not create a HTTP request: %w", method, err)
 	}
 
 	if etag != "" {
 		req.Header.Set("If-None-Match", etag)
 	}
 
+	// the elements are matched by namespace, so that a feed with
+	// prefixed elements, e.g. <atom:feed>, decodes the same.
 	type feed struct {
+		XMLName xml.Name  `xml:"http://www.w3.org/2005/Atom feed"`
 		Etag    string    `xml:"etag,attr"`
-		Updated time.Time `xml:"updated"`
+		Updated time.Time `xml:"http://www.w3.org/2005/Atom updated"`
+		Title   string    `xml:"http://www.w3.org/2005/Atom title"`
 		//		TotalResults int           `xml:"totalResults"`
-		Links    []Link        `xml:"link"`
-		Contacts []ContactKind `xml:"http://www.w3.org/2005/Atom entry"`
+		Links   []Link      `xml:"http://www.w3.org/2005/Atom link"`
+		Entries []feedEntry `xml:"http://www.w3.org/2005/Atom entry"`
 	}
 
 	st := new(QueryStatus)
-	ret := make([]*ContactKind, 0, 20)
 	var f *feed
-	for req != nil {
+	for pageNum := 1; req != nil; pageNum++ {
+		// the failure of a later page is a PageError, so that the contacts
+		// of the earlier pages can be kept.
+		pageErr := func(err error) error {
+			if pageNum > 1 {
+				return &PageError{Page: pageNum, Err: err}
+			}
+			return err
+		}
+
 		res, err := s.base.Do(req)
 		if err != nil {
-			return nil, nil, err
+			return nil, pageErr(err)
+		}
+		if res.StatusCode == http.StatusNotModified {
+			// only the first page is conditional.
+			res.Body.Close()
+			return nil, fmt.Errorf("%s error: %w", method, ErrNotModified)
+		}
+		if res.StatusCode != http.StatusOK {
+			defer res.Body.Close()
+			return nil, pageErr(fmt.Errorf("%s error: %w", method, newAPIError(res)))
 		}
 		f = new(feed)
 		dec := xml.NewDecoder(res.Body)
-		if err = dec.Decode(f); err != nil {
+		if req.URL.Query().Get("alt") == "rss" {
+			var r rssFeed
+			err = dec.Decode(&r)
+			f.Etag, f.Updated, f.Title = r.Etag, r.updated(), r.Channel.Title
+			f.Links, f.Entries = r.Channel.Links, r.entries()
+		} else {
+			err = dec.Decode(f)
+		}
+		if err != nil {
 			defer res.Body.Close()
-			return nil, nil, fmt.Errorf("ListContact error: %w", err)
+			return nil, pageErr(fmt.Errorf("%s error: %w", method, err))
 		}
 		res.Body.Close()
-		for _, ct := range f.Contacts {
+		contacts, err := s.feedContacts(method, f.Entries)
+		if err != nil {
+			return nil, pageErr(err)
+		}
+		page := make([]*ContactKind, 0, len(contacts))
+		for _, ct := range contacts {
 			o := ct.Clone()
-			ret = append(ret, &o)
+			if s.filter != nil && !s.filter(&o) {
+				continue
+			}
+			page = append(page, &o)
+		}
+		if err = fn(page); err != nil {
+			return nil, err
 		}
 
+		// a feed without a next link, or without any link, is the last page.
+		var next string
 		for _, l := range f.Links {
 			if l.Related == "next" {
-				req, _ = http.NewRequestWithContext(ctx, http.MethodGet, l.Href, nil)
+				next = l.Href
 				break
 			}
-			req = nil
+		}
+		if next == "" && s.linkHeader {
+			next = nextLinkHeader(res.Header)
+		}
+		req = nil
+		if next != "" {
+			if req, err = http.NewRequestWithContext(ctx, http.MethodGet, next, nil); err != nil {
+				// the contacts of this page are kept, the next one fails.
+				return nil, &PageError{Page: pageNum + 1, Err: fmt.Errorf("%s error: could not create a HTTP request: %w", method, err)}
+			}
 		}
 		if req == nil {
 			st.Etag = f.Etag
 			st.Updated = f.Updated
+			st.FeedTitle = f.Title
 		}
 	}
 
+	return st, nil
+}
+
+// ListContactsMap retrieves contacts like ListContacts, keyed by GetID.
+// A contact that appears more than once, e.g. because it was edited while
+// the pages were fetched, keeps its latest edited version.
+func (s *service) ListContactsMap(ctx context.Context, projection, etag string, queries ...func(url.Values)) (map[string]*ContactKind, *QueryStatus, error) {
+	cs, st, err := s.ListContacts(ctx, projection, etag, queries...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ret := make(map[string]*ContactKind, len(cs))
+	for _, c := range cs {
+		if o, ok := ret[c.GetID()]; ok && o.GetEdited().After(c.GetEdited()) {
+			continue
+		}
+		ret[c.GetID()] = c
+	}
 	return ret, st, nil
 }
 
-func (s *service) UpdateContact(ctx context.Context, id, etag string, p *ContactKind) (*ContactKind, error) {
-	op, err := s.getContact(ctx, id, "full", "", "UpdateContact error: could not get a contact")
+// nextLinkHeader returns the target of the next link of the RFC 5988 Link
+// headers h, e.g. `<https://example.com/feed?start-index=26>; rel="next"`.
+func nextLinkHeader(h http.Header) string {
+	for _, v := range h.Values("Link") {
+		for _, link := range strings.Split(v, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(k, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(v, `"`)) {
+					if rel == "next" {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// feedContacts returns the contacts of the decoded feed entries.
+// An entry that failed to decode fails the whole feed, unless lenient
+// decoding is enabled, in which case the entry is logged and skipped.
+func (s *service) feedContacts(method string, entries []feedEntry) ([]ContactKind, error) {
+	ret := make([]ContactKind, 0, len(entries))
+	for i, e := range entries {
+		if e.err != nil {
+			if !s.lenient {
+				return nil, fmt.Errorf("%s error: %w", method, e.err)
+			}
+			s.logf("%s: skipped entry %d of the feed: %v", method, i, e.err)
+			continue
+		}
+		ret = append(ret, e.contact)
+	}
+	return ret, nil
+}
+
+// logf logs through the logger of the service, if any.
+func (s *service) logf(format string, v ...interface{}) {
+	if s.logger != nil {
+		s.logger.Printf(format, v...)
+	}
+}
+
+// CountContacts returns the total number of contacts the server reports
+// for the queries. Only the first page of a single entry is fetched.
+// The count is the server's; it ignores WithFilter.
+func (s *service) CountContacts(ctx context.Context, projection string, queries ...func(url.Values)) (int, error) {
+	_, total, err := s.Page(ctx, projection, 1, 1, queries...)
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("CountContacts error: %w", err)
+	}
+	return total, nil
+}
+
+// listURL returns the feed url of projection with the queries applied.
+func (s *service) listURL(projection string, queries []func(url.Values)) (string, error) {
+	if len(queries) == 0 {
+		return fmt.Sprintf("%s/%s", s.endpoint, s.getPojection(projection)), nil
 	}
 
-	if op.etag != etag && etag != "*" {
-		return nil, fmt.Errorf("UpdateContact error: etag not match")
+	params := url.Values{}
+	// add strict
+	withStrict()(params)
+	if err := applyQueries(params, queries); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s?%s", s.endpoint, s.getPojection(projection), params.Encode()), nil
+}
+
+// Page retrieves the pageNum-th page of pageSize contacts, 1-based, and the
+// total number of contacts the server reports for the queries.
+// Unlike ListContacts, it doesn't follow the next links.
+//
+// Pages are fetched with WithStartIndex and WithMaxResults, so they share
+// their caveat: contacts inserted or deleted between two calls shift the
+// following pages, and a contact may be skipped or seen twice.
+func (s *service) Page(ctx context.Context, projection string, pageNum, pageSize int, queries ...func(url.Values)) ([]*ContactKind, int, error) {
+	if pageNum < 1 || pageSize < 1 {
+		return nil, 0, fmt.Errorf("Page error: invalid page %d of size %d", pageNum, pageSize)
 	}
+	queries = append(queries[:len(queries):len(queries)],
+		WithStartIndex((pageNum-1)*pageSize+1),
+		WithMaxResults(pageSize),
+	)
 
-	url := op.editLink
+	u, err := s.listURL(projection, queries)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Page error: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Page error: could not create a HTTP request: %w", err)
+	}
+	res, err := s.base.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Page error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("Page error: %w", newAPIError(res))
+	}
+
+	type feed struct {
+		XMLName      xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+		TotalResults int         `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+		Entries      []feedEntry `xml:"http://www.w3.org/2005/Atom entry"`
+	}
+	f := new(feed)
+	if err = xml.NewDecoder(res.Body).Decode(f); err != nil {
+		return nil, 0, fmt.Errorf("Page error: %w", err)
+	}
+	contacts, err := s.feedContacts("Page", f.Entries)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ret := make([]*ContactKind, 0, len(contacts))
+	for i := range contacts {
+		if s.filter != nil && !s.filter(&contacts[i]) {
+			continue
+		}
+		ret = append(ret, &contacts[i])
+	}
+	return ret, f.TotalResults, nil
+}
+
+func (s *service) UpdateContact(ctx context.Context, id, etag string, p *ContactKind) (*ContactKind, error) {
+	validate := ContactKind.validateForUpdate
+	if etag == "*" {
+		// the wildcard overwrites any version, so p needs no version of its own.
+		validate = ContactKind.validate
+	}
+	if err := validate(s.encode.apply(*p), s.propertyLimits); err != nil {
+		return nil, fmt.Errorf("UpdateContact error: %w", err)
+	}
+
+	// the wildcard overwrites any version, so there is nothing to compare
+	// and the edit link of the full projection can be used directly. An
+	// empty etag matches no version and fails with ErrConflict.
+	url := fmt.Sprintf("%s/full/%s", s.endpoint, id)
+	if etag != "*" || s.skipNoop {
+		op, err := s.getContact(ctx, id, "full", "", "UpdateContact error: could not get a contact")
+		if err != nil {
+			return nil, err
+		}
+		if etag != "*" {
+			if op.etag != etag {
+				return nil, fmt.Errorf("UpdateContact error: %w", ErrConflict)
+			}
+			url = op.editLink
+		}
+		// compare what would be sent, e.g. with the primary phone fixed.
+		if s.skipNoop && op.Equal(s.encode.apply(*p)) {
+			s.logf("UpdateContact: %s unchanged, not sent", id)
+			return op, nil
+		}
+	}
+
+	// maybe merge op and p
+	return s.putContact(ctx, url, etag, p, "UpdateContact")
+}
+
+// TouchContact re-sends the current data of a contact, so that the server
+// bumps its updated time and it shows up again in incremental syncs
+// (WithUpdateMin). If etag is provided, only the version is met will be touched.
+//
+// The entry is sent back verbatim, as GetContactRaw gets it, so the
+// elements ContactKind doesn't model, such as websites or events, are kept.
+func (s *service) TouchContact(ctx context.Context, id, etag string) (*ContactKind, error) {
+	const errPrefix = "TouchContact error: could not get a contact"
+	var raw []byte
+	if _, err := s.getEntry(ctx, id, "full", "", errPrefix, &raw); err != nil {
+		return nil, err
+	}
+	var op entryMeta
+	if err := xml.Unmarshal(raw, &op); err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if etag != "" && etag != "*" && op.etag != etag {
+		return nil, fmt.Errorf("TouchContact error: %w", ErrConflict)
+	}
+
+	// If-Match the fetched version, so that a change made since the GET isn't overwritten.
+	return s.putEntry(ctx, op.editLink, op.etag, bytes.NewReader(raw), "TouchContact")
+}
+
+// putContact replaces the contact at url with p.
+func (s *service) putContact(ctx context.Context, url, etag string, p *ContactKind, method string) (*ContactKind, error) {
 	buf := &bytes.Buffer{}
 	enc := xml.NewEncoder(buf)
-	// maybe merge op and p
-	err = enc.Encode(p)
+	err := enc.Encode(payload{contact: p, opts: s.encode})
 	if err != nil {
 		defer enc.Close()
-		return nil, fmt.Errorf("could not encode xml payload from UpdateContact: %w", err)
+		return nil, fmt.Errorf("could not encode xml payload from %s: %w", method, err)
 	}
 	enc.Close()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, buf)
+	return s.putEntry(ctx, url, etag, buf, method)
+}
+
+// putEntry replaces the contact at url with the entry XML read from body.
+func (s *service) putEntry(ctx context.Context, url, etag string, body io.Reader, method string) (*ContactKind, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("could not create a HTTP request from UpdateContact: %w", err)
+		return nil, fmt.Errorf("could not create a HTTP request from %s: %w", method, err)
 	}
 
 	// If-Match
@@ -358,13 +1240,17 @@ func (s *service) UpdateContact(ctx context.Context, id, etag string, p *Contact
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expect get HTTP status OK, got: %s", res.Status)
+	switch res.StatusCode {
+	case http.StatusOK:
+	default:
+		// HTTP 409 and 412 mean the contact changed between the preliminary
+		// GET and the PUT, and match ErrConflict.
+		return nil, fmt.Errorf("%s error: %w", method, newAPIError(res))
 	}
 
 	dec := xml.NewDecoder(res.Body)
-	defer res.Body.Close()
 	var ret ContactKind
 	if err = dec.Decode(&ret); err != nil {
 		return nil, err
@@ -375,13 +1261,15 @@ func (s *service) UpdateContact(ctx context.Context, id, etag string, p *Contact
 
 // DeleteContact delete a contact.
 func (s *service) DeleteContact(ctx context.Context, id, etag string) error {
-	op, err := s.getContact(ctx, id, "thin", "", "could not get a contact from DeleteContact")
-	if err != nil {
+	// only the version and the edit link are needed, so the thin entry isn't
+	// decoded as a contact, whose category check may reject it.
+	var op entryMeta
+	if _, err := s.getEntry(ctx, id, "thin", "", "could not get a contact from DeleteContact", &op); err != nil {
 		return err
 	}
 
 	if op.etag != etag && etag != "*" {
-		return fmt.Errorf("UpdateContact error: etag not match")
+		return fmt.Errorf("DeleteContact error: %w", ErrConflict)
 	}
 
 	url := op.editLink
@@ -392,10 +1280,16 @@ func (s *service) DeleteContact(ctx context.Context, id, etag string) error {
 
 	// If-Match
 	req.Header.Set("If-Match", etag)
-	_, err = s.base.Do(req)
+	res, err := s.base.Do(req)
 	if err != nil {
 		return fmt.Errorf("DeleteContact error: failed to call: %w", err)
 	}
+	defer res.Body.Close()
 
-	return err
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("DeleteContact error: %w", newAPIError(res))
+	}
 }
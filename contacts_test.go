@@ -0,0 +1,1450 @@
+package contacts
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rewriteTransport sends requests for the gdata host to a test server.
+type rewriteTransport struct{ target *url.URL }
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == "www.google.com" {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = rt.target.Scheme
+		req.URL.Host = rt.target.Host
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestService returns a service for the domain example.com whose requests
+// are served by h.
+func newTestService(t *testing.T, h http.Handler, opts ...ServiceOption) *service {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parse test server url: %v", err)
+	}
+
+	svc, err := NewService(&http.Client{Transport: rewriteTransport{u}}, "example.com", "", opts...)
+	if err != nil {
+		t.Fatalf("NewService error: %v", err)
+	}
+	return svc.(*service)
+}
+
+// testEntry returns a contact entry with the given id and etag.
+// extra is inserted as additional child elements.
+func testEntry(id, etag, extra string) string {
+	return fmt.Sprintf(`<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='%s'>
+  <id>http://www.google.com/m8/feeds/contacts/example.com/base/%s</id>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <link rel='self' type='application/atom+xml' href='https://www.google.com/m8/feeds/contacts/example.com/full/%s'/>
+  <link rel='edit' type='application/atom+xml' href='https://www.google.com/m8/feeds/contacts/example.com/full/%s'/>
+  %s
+</entry>`, etag, id, id, id, extra)
+}
+
+// writeEntry writes a minimal contact entry with the given id and etag.
+func writeEntry(w http.ResponseWriter, id, etag string) {
+	fmt.Fprint(w, testEntry(id, etag, ""))
+}
+
+// writeFeed writes a contacts feed with the given etag and entries.
+// If next is not empty, the feed links to it as the next page.
+func writeFeed(w http.ResponseWriter, etag, next string, entries ...string) {
+	fmt.Fprintf(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='%s'>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+  <link rel='self' type='application/atom+xml' href='https://www.google.com/m8/feeds/contacts/example.com/full'/>
+`, etag)
+	if next != "" {
+		fmt.Fprintf(w, "  <link rel='next' type='application/atom+xml' href='%s'/>\n", next)
+	}
+	for _, e := range entries {
+		fmt.Fprintln(w, e)
+	}
+	fmt.Fprint(w, `</feed>`)
+}
+
+// TestRedirectKeepsGDataHeaders checks that the transport sets the GData
+// headers on the redirected request too, and that the policy is consulted.
+func TestRedirectKeepsGDataHeaders(t *testing.T) {
+	media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get("GData-Version"); v != gdataVersion {
+			t.Errorf("redirected request: expect GData-Version %q, got %q", gdataVersion, v)
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("photo"))
+	}))
+	defer media.Close()
+
+	var redirects int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, media.URL+"/photo", http.StatusFound)
+	}), WithCheckRedirect(func(req *http.Request, via []*http.Request) error {
+		redirects++
+		return nil
+	}))
+
+	res, err := s.base.Get("https://www.google.com/m8/feeds/photos/media/example.com/abc")
+	if err != nil {
+		t.Fatalf("get photo error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expect HTTP status OK, got %s", res.Status)
+	}
+	if redirects != 1 {
+		t.Fatalf("expect redirect policy called once, got %d", redirects)
+	}
+}
+
+func TestRedirectDropsAuthorization(t *testing.T) {
+	media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get("Authorization"); v != "" {
+			t.Errorf("redirected request: expect no Authorization on another host, got %q", v)
+		}
+		w.Write([]byte("photo"))
+	}))
+	defer media.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("expect Authorization on the original request")
+		}
+		http.Redirect(w, r, media.URL+"/photo", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	// both servers listen on 127.0.0.1, so only the port tells the hosts apart.
+	svc, err := NewService(&http.Client{}, "example.com", "")
+	if err != nil {
+		t.Fatalf("NewService error: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, origin.URL+"/m8/feeds/photos/media/example.com/abc", nil)
+	if err != nil {
+		t.Fatalf("create request error: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	res, err := svc.(*service).base.Do(req)
+	if err != nil {
+		t.Fatalf("get photo error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expect HTTP status OK, got %s", res.Status)
+	}
+}
+
+func TestRedirectPolicyStops(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.String(), http.StatusFound)
+	}), WithCheckRedirect(func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}))
+
+	res, err := s.base.Get("https://www.google.com/m8/feeds/photos/media/example.com/abc")
+	if err != nil {
+		t.Fatalf("get photo error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("expect HTTP status Found, got %s", res.Status)
+	}
+}
+
+func TestRedirectDefaultLimit(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.String(), http.StatusFound)
+	}))
+
+	_, err := s.base.Get("https://www.google.com/m8/feeds/photos/media/example.com/abc")
+	var uerr *url.Error
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expect redirect loop to fail, got %v", err)
+	}
+}
+
+func TestServiceConcurrentUse(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/full") {
+			writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, ""), testEntry("def", `"v1"`, ""))
+			return
+		}
+		writeEntry(w, "abc", `"v1"`)
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parse test server url: %v", err)
+	}
+
+	client := &http.Client{Transport: rewriteTransport{u}}
+	svc, err := NewService(client, "example.com", "", WithRetry(2, time.Millisecond), WithLogger(log.New(io.Discard, "", 0)))
+	if err != nil {
+		t.Fatalf("NewService error: %v", err)
+	}
+	if _, ok := client.Transport.(rewriteTransport); !ok || client.CheckRedirect != nil {
+		t.Fatalf("expect the client not modified, got %T", client.Transport)
+	}
+
+	errs := make(chan error, 100)
+	for i := 0; i < cap(errs); i++ {
+		go func(i int) {
+			if i%2 == 0 {
+				_, err := svc.GetContact(context.Background(), "abc", "", "")
+				errs <- err
+				return
+			}
+			_, _, err := svc.ListContacts(context.Background(), "", "")
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("concurrent call error: %v", err)
+		}
+	}
+}
+
+func TestCloneData(t *testing.T) {
+	c := ContactKind{
+		Name:             GDName{FullName: "Elizabeth Bennet"},
+		Email:            []GDEmail{{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#home"}},
+		ExtendedProperty: map[string]string{"source": "import"},
+		editLink:         "https://www.google.com/m8/feeds/contacts/example.com/full/abc",
+		photoLink:        "https://www.google.com/m8/feeds/photos/media/example.com/abc",
+		selfLink:         "https://www.google.com/m8/feeds/contacts/example.com/full/abc",
+		id:               "http://www.google.com/m8/feeds/contacts/example.com/base/abc",
+		updated:          time.Now(),
+		content:          "note",
+		etag:             `"etag"`,
+	}
+
+	d := c.CloneData()
+	if d.GetEditLink() != "" || d.GetPhotoLink() != "" || d.selfLink != "" || d.GetID() != "" ||
+		d.GetEtag() != "" || !d.GetUpdated().IsZero() {
+
+		t.Fatalf("CloneData should reset metadata, got %+v", d)
+	}
+	if d.Name.FullName != "Elizabeth Bennet" || d.ExtendedProperty["source"] != "import" || d.content != "note" {
+		t.Fatalf("CloneData should keep data, got %+v", d)
+	}
+
+	d.ExtendedProperty["source"] = "changed"
+	if c.ExtendedProperty["source"] != "import" {
+		t.Fatalf("CloneData should not share maps with the source")
+	}
+}
+
+func TestCloneLength(t *testing.T) {
+	c := ContactKind{Email: []GDEmail{{Address: "liz@gmail.com"}, {Address: "liz@example.org"}}}
+
+	d := c.Clone()
+	if len(d.Email) != 2 || d.Email[0].Address != "liz@gmail.com" || d.Email[1].Address != "liz@example.org" {
+		t.Fatalf("expect the 2 emails cloned, got %+v", d.Email)
+	}
+}
+
+func TestCloneCopiesAllFields(t *testing.T) {
+	var c ContactKind
+	v := reflect.ValueOf(&c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Slice:
+			f.Set(reflect.MakeSlice(f.Type(), 1, 1))
+		case reflect.Map:
+			m := reflect.MakeMap(f.Type())
+			m.SetMapIndex(reflect.Zero(f.Type().Key()), reflect.Zero(f.Type().Elem()))
+			f.Set(m)
+		case reflect.Ptr, reflect.Interface, reflect.Chan, reflect.Func:
+			t.Errorf("field %s: Clone doesn't know how to copy a %s", v.Type().Field(i).Name, f.Kind())
+		}
+	}
+
+	d := reflect.ValueOf(c.Clone())
+	for i := 0; i < v.NumField(); i++ {
+		f, g := v.Field(i), d.Field(i)
+		if !f.CanSet() || (f.Kind() != reflect.Slice && f.Kind() != reflect.Map) {
+			continue
+		}
+		name := v.Type().Field(i).Name
+		if g.Len() != f.Len() {
+			t.Errorf("field %s: expect %d cloned elements, got %d", name, f.Len(), g.Len())
+		}
+		if g.Pointer() == f.Pointer() {
+			t.Errorf("field %s: the clone shares its data with the source", name)
+		}
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != "contacts.test" || r.Header.Get("GData-Version") != gdataVersion {
+			t.Errorf("unexpected proxied request %s, GData-Version %q", r.URL, r.Header.Get("GData-Version"))
+		}
+		proxied = true
+		w.Write([]byte(`<entry xmlns='http://www.w3.org/2005/Atom'>
+  <id>http://www.google.com/m8/feeds/contacts/example.com/base/abc</id>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+</entry>`))
+	}))
+	defer proxy.Close()
+
+	svc, err := NewService(&http.Client{}, "example.com", "", WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("NewService error: %v", err)
+	}
+	s := svc.(*service)
+	s.endpoint = "http://contacts.test/m8/feeds/contacts/example.com"
+
+	c, err := s.GetContact(context.Background(), "abc", "", "")
+	if err != nil {
+		t.Fatalf("GetContact error: %v", err)
+	}
+	if !proxied || c.GetID() != "abc" {
+		t.Fatalf("expect the request to go through the proxy")
+	}
+
+	if _, err = NewService(&http.Client{Transport: rewriteTransport{}}, "example.com", "", WithProxy(proxy.URL)); err == nil {
+		t.Fatalf("expect error for a transport that can't be configured")
+	}
+}
+
+func TestWithInsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("GData-Version") != gdataVersion {
+			t.Errorf("expect GData-Version %q, got %q", gdataVersion, r.Header.Get("GData-Version"))
+		}
+		writeEntry(w, "abc", `"v1"`)
+	}))
+	defer srv.Close()
+
+	svc, err := NewService(&http.Client{}, "example.com", "", WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("NewService error: %v", err)
+	}
+	s := svc.(*service)
+	s.endpoint = srv.URL + "/m8/feeds/contacts/example.com"
+
+	c, err := s.GetContact(context.Background(), "abc", "", "")
+	if err != nil {
+		t.Fatalf("GetContact error: %v", err)
+	}
+	if c.GetID() != "abc" {
+		t.Fatalf("expect contact abc, got %s", c.GetID())
+	}
+
+	svc, err = NewService(&http.Client{Transport: &http.Transport{}}, "example.com", "")
+	if err != nil {
+		t.Fatalf("NewService error: %v", err)
+	}
+	s = svc.(*service)
+	s.endpoint = srv.URL + "/m8/feeds/contacts/example.com"
+	if _, err = s.GetContact(context.Background(), "abc", "", ""); err == nil {
+		t.Fatalf("expect certificate error without WithInsecureSkipVerify")
+	}
+}
+
+func TestContactKindAccessorsCopy(t *testing.T) {
+	c := ContactKind{
+		Email:                   []GDEmail{{Address: "liz@gmail.com"}},
+		PhoneNumber:             []GDPhoneNumber{{DialNumber: "(206)555-1212"}},
+		StructuredPostalAddress: []GDStructuredPostalAddress{{City: "Mountain View"}},
+		IM:                      []GDIM{{Address: "liz@gmail.com"}},
+		ExtendedProperty:        map[string]string{"source": "import"},
+	}
+
+	emails := c.Emails()
+	emails[0].Address = "changed"
+	phones := c.Phones()
+	phones[0].DialNumber = "changed"
+	addresses := c.Addresses()
+	addresses[0].City = "changed"
+	ims := c.IMs()
+	ims[0].Address = "changed"
+	props := c.ExtendedProperties()
+	props["source"] = "changed"
+
+	if c.Email[0].Address != "liz@gmail.com" || c.PhoneNumber[0].DialNumber != "(206)555-1212" ||
+		c.StructuredPostalAddress[0].City != "Mountain View" || c.IM[0].Address != "liz@gmail.com" ||
+		c.ExtendedProperty["source"] != "import" {
+
+		t.Fatalf("accessors should return copies, got %+v", c)
+	}
+}
+
+func TestWithSinglePrimaryPhone(t *testing.T) {
+	c := &ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		PhoneNumber: []GDPhoneNumber{
+			{Related: "http://schemas.google.com/g/2005#work", Primary: true, DialNumber: "(206)555-1212"},
+			{Related: "http://schemas.google.com/g/2005#home", Primary: true, DialNumber: "(206)555-1213"},
+		},
+	}
+
+	var body string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusCreated)
+		writeEntry(w, "abc", `"etag"`)
+	})
+
+	// the server would reject the contact, so it's caught before sending.
+	if _, err := newTestService(t, h).CreateContact(context.Background(), c); err == nil || body != "" {
+		t.Fatalf("expect a validation error without sending, got %v", err)
+	}
+
+	if _, err := newTestService(t, h, WithSinglePrimaryPhone()).CreateContact(context.Background(), c); err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	if n := strings.Count(body, `primary="true"`); n != 1 {
+		t.Fatalf("expect 1 primary phone number, got %d in %s", n, body)
+	}
+	if !c.PhoneNumber[1].Primary {
+		t.Fatalf("the contact should not be modified")
+	}
+}
+
+func TestWithExplicitPrimaryFalse(t *testing.T) {
+	c := &ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		Email: []GDEmail{
+			{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#home", Primary: true},
+			{Address: "liz@example.org", Related: "http://schemas.google.com/g/2005#other"},
+			{Address: "liz@example.com", Related: "http://schemas.google.com/g/2005#work"},
+		},
+	}
+	if !c.SetPrimaryEmail("Liz@Example.com") {
+		t.Fatalf("expect the email found")
+	}
+	if c.Email[0].Primary || !c.Email[2].Primary {
+		t.Fatalf("expect the primary email moved, got %+v", c.Email)
+	}
+
+	var body string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusCreated)
+		writeEntry(w, "abc", `"etag"`)
+	})
+
+	if _, err := newTestService(t, h).CreateContact(context.Background(), c); err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	if strings.Contains(body, `primary="false"`) {
+		t.Fatalf("expect no explicit false by default, got %s", body)
+	}
+
+	if _, err := newTestService(t, h, WithExplicitPrimaryFalse()).CreateContact(context.Background(), c); err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	if !strings.Contains(body, `address="liz@gmail.com" rel="http://schemas.google.com/g/2005#home" primary="false"`) {
+		t.Fatalf("expect the demoted email sent with primary false, got %s", body)
+	}
+	if n := strings.Count(body, `primary="false"`); n != 1 {
+		t.Fatalf("expect only the demoted email with primary false, got %d in %s", n, body)
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	const work = "http://schemas.google.com/g/2005#work"
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFeed(w, `"feed"`, "",
+			testEntry("a", `"a"`, `<gd:email rel='http://schemas.google.com/g/2005#work' address='a@example.com'/>`),
+			testEntry("b", `"b"`, `<gd:email rel='http://schemas.google.com/g/2005#home' address='b@example.org'/>`),
+			testEntry("c", `"c"`, `<gd:email rel='http://schemas.google.com/g/2005#work' address='c@example.com'/>`),
+		)
+	}), WithFilter(func(c *ContactKind) bool {
+		for _, m := range c.Email {
+			if m.Related == work {
+				return true
+			}
+		}
+		return false
+	}))
+
+	ret, _, err := s.ListContacts(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if len(ret) != 2 || ret[0].GetID() != "a" || ret[1].GetID() != "c" {
+		t.Fatalf("expect contacts a and c, got %v", ret)
+	}
+}
+
+func TestUpdateContactConflict(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeEntry(w, "abc", `"v1"`)
+		case http.MethodPut:
+			if r.Header.Get("If-Match") != `"v1"` {
+				t.Errorf("expect If-Match %q, got %q", `"v1"`, r.Header.Get("If-Match"))
+			}
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	}))
+
+	p := &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}}
+	if _, err := s.UpdateContact(context.Background(), "abc", `"v1"`, p); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for HTTP 412, got %v", err)
+	}
+	if _, err := s.UpdateContact(context.Background(), "abc", `"v0"`, p); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for a stale etag, got %v", err)
+	}
+	if _, err := s.UpdateContact(context.Background(), "abc", "", p); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for an empty etag, got %v", err)
+	}
+}
+
+func TestUpdateContactWildcard(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/m8/feeds/contacts/example.com/full/abc" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("If-Match") != "*" {
+			t.Errorf("expect If-Match *, got %q", r.Header.Get("If-Match"))
+		}
+		writeEntry(w, "abc", `"v2"`)
+	}))
+
+	ret, err := s.UpdateContact(context.Background(), "abc", "*", &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}})
+	if err != nil {
+		t.Fatalf("UpdateContact error: %v", err)
+	}
+	if ret.GetEtag() != `"v2"` {
+		t.Fatalf("expect updated etag, got %q", ret.GetEtag())
+	}
+
+	// a contact with an id but without its version, e.g. from an export,
+	// may still be overwritten.
+	p := &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}}
+	p.SetID("http://www.google.com/m8/feeds/contacts/example.com/base/abc")
+	if _, err = s.UpdateContact(context.Background(), "abc", "*", p); err != nil {
+		t.Fatalf("UpdateContact error: %v", err)
+	}
+}
+
+func TestUpdateContactSkipNoop(t *testing.T) {
+	const extra = `<gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>
+  <gd:email rel='http://schemas.google.com/g/2005#work' address='liz@gmail.com'/>`
+	var puts int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts++
+		}
+		fmt.Fprint(w, testEntry("abc", `"v1"`, extra))
+	}), WithSkipNoopUpdates())
+
+	p := &ContactKind{
+		Name:  GDName{FullName: "Elizabeth Bennet"},
+		Email: []GDEmail{{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#work"}},
+	}
+	for _, etag := range []string{`"v1"`, "*"} {
+		ret, err := s.UpdateContact(context.Background(), "abc", etag, p)
+		if err != nil {
+			t.Fatalf("UpdateContact error: %v", err)
+		}
+		if puts != 0 {
+			t.Fatalf("expect no PUT for an unchanged contact with etag %s", etag)
+		}
+		if ret.GetEtag() != `"v1"` {
+			t.Fatalf("expect the current version, got %q", ret.GetEtag())
+		}
+	}
+
+	p.Email[0].Address = "liz@example.org"
+	if _, err := s.UpdateContact(context.Background(), "abc", `"v1"`, p); err != nil {
+		t.Fatalf("UpdateContact error: %v", err)
+	}
+	if puts != 1 {
+		t.Fatalf("expect a PUT for a changed contact, got %d", puts)
+	}
+}
+
+func TestUpdateContactGroupMembershipDeleted(t *testing.T) {
+	var body string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		writeEntry(w, "abc", `"v2"`)
+	}))
+
+	p := &ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		GroupMembership: []GroupMembershipInfo{
+			{Href: "http://www.google.com/m8/feeds/groups/example.com/base/6"},
+			{Href: "http://www.google.com/m8/feeds/groups/example.com/base/7", Deleted: true},
+		},
+	}
+	if _, err := s.UpdateContact(context.Background(), "abc", "*", p); err != nil {
+		t.Fatalf("UpdateContact error: %v", err)
+	}
+	for _, want := range []string{
+		`<gContact:groupMembershipInfo href="http://www.google.com/m8/feeds/groups/example.com/base/6"></gContact:groupMembershipInfo>`,
+		`<gContact:groupMembershipInfo href="http://www.google.com/m8/feeds/groups/example.com/base/7" deleted="true"></gContact:groupMembershipInfo>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expect %s in the request body, got %s", want, body)
+		}
+	}
+}
+
+func TestGetContactRaw(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, testEntry("abc", `"v1"`, "<gd:unknown/>"))
+	}))
+
+	raw, err := s.GetContactRaw(context.Background(), "abc", "full", "")
+	if err != nil {
+		t.Fatalf("GetContactRaw error: %v", err)
+	}
+	if !strings.Contains(string(raw), "<entry") || !strings.Contains(string(raw), "<gd:unknown/>") {
+		t.Fatalf("expect the entry verbatim, got %s", raw)
+	}
+
+	raw, err = s.GetContactRaw(context.Background(), "abc", "full", `"v1"`)
+	if !errors.Is(err, ErrNotModified) || raw != nil {
+		t.Fatalf("expect ErrNotModified and nil bytes, got %q, %v", raw, err)
+	}
+}
+
+func TestGetContactNotModified(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != `"v1"` {
+			t.Errorf("expect If-None-Match header, got %q", r.Header.Get("If-None-Match"))
+		}
+		w.WriteHeader(http.StatusNotModified)
+	}))
+
+	c, err := s.GetContact(context.Background(), "abc", "full", `"v1"`)
+	if !errors.Is(err, ErrNotModified) || c != nil {
+		t.Fatalf("expect ErrNotModified and nil contact, got %v, %v", c, err)
+	}
+}
+
+func TestListContactsNotModified(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != `"feed"` {
+			t.Errorf("expect If-None-Match header, got %q", r.Header.Get("If-None-Match"))
+		}
+		w.WriteHeader(http.StatusNotModified)
+	}))
+
+	ret, st, err := s.ListContacts(context.Background(), "full", `"feed"`)
+	if !errors.Is(err, ErrNotModified) || ret != nil || st != nil {
+		t.Fatalf("expect ErrNotModified and no results, got %v, %v, %v", ret, st, err)
+	}
+	var pe *PageError
+	if errors.As(err, &pe) {
+		t.Fatalf("expect no page error for the first page, got %v", pe)
+	}
+}
+
+// prefixedFeed is a feed whose Atom elements have a prefix instead of
+// being in the default namespace.
+const prefixedFeed = `<atom:feed xmlns:atom='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"feed"'>
+  <atom:updated>2023-08-18T09:54:17.202Z</atom:updated>
+  <atom:title>Example contacts</atom:title>
+  <atom:entry gd:etag='"v1"'>
+    <atom:id>http://www.google.com/m8/feeds/contacts/example.com/base/abc</atom:id>
+    <atom:category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+    <atom:link rel='edit' type='application/atom+xml' href='https://www.google.com/m8/feeds/contacts/example.com/full/abc'/>
+    <gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>
+  </atom:entry>
+</atom:feed>`
+
+func TestListContactsPrefixedFeed(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, prefixedFeed)
+	}))
+
+	ret, st, err := s.ListContacts(context.Background(), "full", "")
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if len(ret) != 1 || ret[0].GetID() != "abc" || ret[0].GetEtag() != `"v1"` || ret[0].Name.FullName != "Elizabeth Bennet" {
+		t.Fatalf("expect the prefixed entry decoded, got %v", ret)
+	}
+	if ret[0].GetEditLink() != "https://www.google.com/m8/feeds/contacts/example.com/full/abc" {
+		t.Fatalf("expect the edit link decoded, got %q", ret[0].GetEditLink())
+	}
+	if st.Etag != `"feed"` || st.FeedTitle != "Example contacts" || st.Updated.IsZero() {
+		t.Fatalf("expect the feed data decoded, got %+v", st)
+	}
+
+	var n int
+	if _, err = s.StreamContacts(context.Background(), "full", "", func(c *ContactKind) error {
+		n++
+		return nil
+	}); err != nil || n != 1 {
+		t.Fatalf("expect StreamContacts to decode 1 contact, got %d, %v", n, err)
+	}
+}
+
+func TestListContactsProjection(t *testing.T) {
+	var path string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		writeFeed(w, `"feed"`, "")
+	}))
+	s.projection = "thin"
+
+	for _, tc := range []struct {
+		projection string
+		queries    []func(url.Values)
+		expect     string
+	}{
+		{"", nil, "/m8/feeds/contacts/example.com/thin"},
+		{"full", nil, "/m8/feeds/contacts/example.com/full"},
+		{"", []func(url.Values){WithMaxResults(5)}, "/m8/feeds/contacts/example.com/thin"},
+		{"full", []func(url.Values){WithMaxResults(5)}, "/m8/feeds/contacts/example.com/full"},
+	} {
+		if _, _, err := s.ListContacts(context.Background(), tc.projection, "", tc.queries...); err != nil {
+			t.Fatalf("ListContacts error: %v", err)
+		}
+		if path != tc.expect {
+			t.Errorf("projection %q with %d queries: expect %s, got %s", tc.projection, len(tc.queries), tc.expect, path)
+		}
+	}
+}
+
+func TestIterateContactsBreak(t *testing.T) {
+	var requests []string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("start-index"))
+		if r.URL.Query().Get("start-index") == "3" {
+			writeFeed(w, `"feed"`, "", testEntry("ghi", `"v1"`, ""))
+			return
+		}
+		writeFeed(w, `"feed"`, "https://www.google.com/m8/feeds/contacts/example.com/full?start-index=3",
+			testEntry("abc", `"v1"`, ""), testEntry("def", `"v1"`, ""))
+	}))
+
+	var ids []string
+	for c, err := range s.IterateContacts(context.Background(), "", "") {
+		if err != nil {
+			t.Fatalf("IterateContacts error: %v", err)
+		}
+		ids = append(ids, c.GetID())
+		if len(ids) == 2 {
+			break
+		}
+	}
+	if strings.Join(ids, ",") != "abc,def" || len(requests) != 1 {
+		t.Fatalf("expect abc and def from the first page only, got %v with requests %v", ids, requests)
+	}
+
+	ids = ids[:0]
+	for c, err := range s.IterateContacts(context.Background(), "", "") {
+		if err != nil {
+			t.Fatalf("IterateContacts error: %v", err)
+		}
+		ids = append(ids, c.GetID())
+	}
+	if strings.Join(ids, ",") != "abc,def,ghi" {
+		t.Fatalf("expect the contacts of both pages, got %v", ids)
+	}
+}
+
+func TestWithPartialResults(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "3" {
+			http.Error(w, "backend error", http.StatusServiceUnavailable)
+			return
+		}
+		n := 1
+		if page != "" {
+			n, _ = strconv.Atoi(page)
+		}
+		writeFeed(w, `"feed"`, fmt.Sprintf("https://www.google.com/m8/feeds/contacts/example.com/full?page=%d", n+1),
+			testEntry(fmt.Sprintf("c%d", n), `"v1"`, ""))
+	})
+
+	if cs, _, err := newTestService(t, h).ListContacts(context.Background(), "", ""); err == nil || cs != nil {
+		t.Fatalf("expect no contacts by default, got %d, %v", len(cs), err)
+	}
+
+	cs, st, err := newTestService(t, h, WithPartialResults()).ListContacts(context.Background(), "", "")
+	var pe *PageError
+	if !errors.As(err, &pe) || pe.Page != 3 {
+		t.Fatalf("expect page 3 failed, got %v", err)
+	}
+	if len(cs) != 2 || cs[0].GetID() != "c1" || cs[1].GetID() != "c2" || st != nil {
+		t.Fatalf("expect the contacts of pages 1 and 2, got %v", cs)
+	}
+}
+
+func TestListContactsMalformedNextLink(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFeed(w, `"feed"`, "https://www.google.com/m8/feeds/contacts/example.com/full/%zz", testEntry("c1", `"v1"`, ""))
+	}), WithPartialResults())
+
+	cs, _, err := s.ListContacts(context.Background(), "", "")
+	var pe *PageError
+	if !errors.As(err, &pe) || pe.Page != 2 {
+		t.Fatalf("expect page 2 failed, got %v", err)
+	}
+	if len(cs) != 1 || cs[0].GetID() != "c1" {
+		t.Fatalf("expect the contacts of page 1, got %v", cs)
+	}
+}
+
+func TestListContactsInto(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, ""), testEntry("def", `"v1"`, ""))
+	}))
+
+	dst := make([]*ContactKind, 3, 10)
+	ret, _, err := s.ListContactsInto(context.Background(), dst, "", "")
+	if err != nil {
+		t.Fatalf("ListContactsInto error: %v", err)
+	}
+	if len(ret) != 2 || ret[0].GetID() != "abc" || ret[1].GetID() != "def" {
+		t.Fatalf("expect contacts abc and def, got %v", ret)
+	}
+	if &ret[0] != &dst[0] {
+		t.Fatalf("expect the capacity of dst reused")
+	}
+}
+
+func TestListContactsFeedTitle(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"feed"'>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+  <title type='text'>Domain Shared Contacts for example.com</title>
+  %s
+</feed>`, testEntry("abc", `"v1"`, "<title>Elizabeth Bennet</title>"))
+	}))
+
+	_, st, err := s.ListContacts(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if st.FeedTitle != "Domain Shared Contacts for example.com" {
+		t.Fatalf("expect the feed title, got %q", st.FeedTitle)
+	}
+}
+
+func TestContactKindSettersRestoreUpdate(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeEntry(w, "abc", `"v1"`)
+		case http.MethodPut:
+			if r.Header.Get("If-Match") != `"v1"` {
+				t.Errorf("expect If-Match %q, got %q", `"v1"`, r.Header.Get("If-Match"))
+			}
+			writeEntry(w, "abc", `"v2"`)
+		}
+	}))
+
+	c, err := s.GetContact(context.Background(), "abc", "", "")
+	if err != nil {
+		t.Fatalf("GetContact error: %v", err)
+	}
+	c.Name.FullName = "Elizabeth Darcy"
+
+	// a cache that keeps the contact data only.
+	cached := ContactKind{Name: c.Name}
+	cached.SetID(c.GetFullID())
+	cached.SetEtag(c.GetEtag())
+	cached.SetEditLink(c.GetEditLink())
+	if cached.GetID() != "abc" || cached.GetEditLink() != c.GetEditLink() {
+		t.Fatalf("expect the metadata restored, got %s %s", cached.GetID(), cached.GetEditLink())
+	}
+
+	ret, err := s.UpdateContact(context.Background(), cached.GetID(), cached.GetEtag(), &cached)
+	if err != nil {
+		t.Fatalf("UpdateContact error: %v", err)
+	}
+	if ret.GetEtag() != `"v2"` {
+		t.Fatalf("expect updated etag, got %q", ret.GetEtag())
+	}
+}
+
+func TestGetContacts(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if id == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		writeEntry(w, id, `"`+id+`"`)
+	}))
+
+	found, errs := s.GetContacts(context.Background(), []string{"a", "b", "missing", "c"}, "", 2)
+	if len(found) != 3 || found["a"].GetID() != "a" || found["b"].GetID() != "b" || found["c"].GetID() != "c" {
+		t.Fatalf("expect contacts a, b and c, got %v", found)
+	}
+	if len(errs) != 1 || !errors.Is(errs["missing"], ErrNotFound) {
+		t.Fatalf("expect ErrNotFound for the missing contact, got %v", errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	found, errs = s.GetContacts(ctx, []string{"a", "b"}, "", 1)
+	if len(found) != 0 || len(errs) != 2 || !errors.Is(errs["a"], context.Canceled) {
+		t.Fatalf("expect context errors, got %v %v", found, errs)
+	}
+}
+
+func TestContactKindIDs(t *testing.T) {
+	c := ContactKind{id: "http://www.google.com/m8/feeds/contacts/legispect.com/base/20017e218fa39973"}
+	if c.GetID() != "20017e218fa39973" {
+		t.Fatalf("expect short id, got %q", c.GetID())
+	}
+	if c.GetFullID() != "http://www.google.com/m8/feeds/contacts/legispect.com/base/20017e218fa39973" {
+		t.Fatalf("expect full id, got %q", c.GetFullID())
+	}
+}
+
+func TestTouchContact(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, testEntry("abc", `"v1"`, `<gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>`))
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(b), "<gd:fullName>Elizabeth Bennet</gd:fullName>") {
+				t.Errorf("expect current data re-sent, got %s", b)
+			}
+			if r.Header.Get("If-Match") != `"v1"` {
+				t.Errorf("expect If-Match %q, got %q", `"v1"`, r.Header.Get("If-Match"))
+			}
+			fmt.Fprint(w, strings.Replace(testEntry("abc", `"v2"`, ""), "2023-08-18T09:54:17.202Z", "2023-09-01T00:00:00.000Z", 1))
+		}
+	}))
+
+	before, err := s.GetContact(context.Background(), "abc", "", "")
+	if err != nil {
+		t.Fatalf("GetContact error: %v", err)
+	}
+	after, err := s.TouchContact(context.Background(), "abc", `"v1"`)
+	if err != nil {
+		t.Fatalf("TouchContact error: %v", err)
+	}
+	if !after.GetUpdated().After(before.GetUpdated()) || after.GetEtag() != `"v2"` {
+		t.Fatalf("expect updated time bumped, got %s -> %s", before.GetUpdated(), after.GetUpdated())
+	}
+
+	if _, err = s.TouchContact(context.Background(), "abc", `"v0"`); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for a stale etag, got %v", err)
+	}
+}
+
+func TestTouchContactKeepsUnknownElements(t *testing.T) {
+	const website = `<gContact:website xmlns:gContact='http://schemas.google.com/contact/2008' href='https://example.com/liz' rel='home-page'/>`
+	entry := testEntry("abc", `"v1"`, website)
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, entry)
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			if string(b) != entry {
+				t.Errorf("expect the entry sent back unchanged, got %s", b)
+			}
+			writeEntry(w, "abc", `"v2"`)
+		}
+	}))
+
+	if _, err := s.TouchContact(context.Background(), "abc", ""); err != nil {
+		t.Fatalf("TouchContact error: %v", err)
+	}
+}
+
+func TestGetContactThinEntryEmail(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/thin/abc") {
+			t.Errorf("expect thin projection, got %s", r.URL.Path)
+		}
+		// a thin entry carries few elements, sometimes an email.
+		fmt.Fprint(w, testEntry("abc", `"v1"`, `<gd:email rel='http://schemas.google.com/g/2005#work' address='liz@example.com' primary='true'/>`))
+	}))
+
+	c, err := s.GetContact(context.Background(), "abc", "thin", "")
+	if err != nil {
+		t.Fatalf("GetContact error: %v", err)
+	}
+	if len(c.Email) != 1 || c.Email[0].Address != "liz@example.com" || !c.Email[0].Primary {
+		t.Fatalf("expect the email of the thin entry, got %+v", c.Email)
+	}
+}
+
+func TestDeleteContactThinEntry(t *testing.T) {
+	var deleted bool
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if !strings.HasSuffix(r.URL.Path, "/thin/abc") {
+				t.Errorf("expect thin projection, got %s", r.URL.Path)
+			}
+			// the thin entry has no contact category.
+			entry := strings.Replace(testEntry("abc", `"v1"`, ""), "#contact'", "#unknown'", 1)
+			fmt.Fprint(w, entry)
+		case http.MethodDelete:
+			if r.Header.Get("If-Match") != `"v1"` {
+				t.Errorf("expect If-Match %q, got %q", `"v1"`, r.Header.Get("If-Match"))
+			}
+			deleted = true
+		}
+	}))
+
+	if err := s.DeleteContact(context.Background(), "abc", `"v1"`); err != nil {
+		t.Fatalf("DeleteContact error: %v", err)
+	}
+	if !deleted {
+		t.Fatalf("expect the contact deleted")
+	}
+
+	deleted = false
+	for _, etag := range []string{`"v0"`, ""} {
+		if err := s.DeleteContact(context.Background(), "abc", etag); !errors.Is(err, ErrConflict) {
+			t.Fatalf("expect ErrConflict for etag %q, got %v", etag, err)
+		}
+	}
+	if deleted {
+		t.Fatalf("expect no DELETE for a stale etag")
+	}
+}
+
+func TestContactKindDisplayEmail(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		email  []GDEmail
+		expect string
+	}{
+		{"display name", []GDEmail{{Address: "liz@example.org"}, {Address: "liz@gmail.com", DisplayName: "Liz Bennet", Primary: true}}, "Liz Bennet <liz@gmail.com>"},
+		{"address only", []GDEmail{{Address: "liz@example.org", Primary: true}}, "liz@example.org"},
+		{"no primary", []GDEmail{{Address: "liz@example.org"}, {Address: "liz@gmail.com"}}, "liz@example.org"},
+		{"no email", nil, ""},
+	} {
+		if got := (ContactKind{Email: tc.email}).DisplayEmail(); got != tc.expect {
+			t.Errorf("%s: expect %q, got %q", tc.name, tc.expect, got)
+		}
+	}
+}
+
+func TestContactKindString(t *testing.T) {
+	c := ContactKind{
+		Name:        GDName{GivenName: "Elizabeth", FamilyName: "Bennet"},
+		Email:       []GDEmail{{Address: "liz@gmail.com"}, {Address: "liz@example.org"}},
+		PhoneNumber: []GDPhoneNumber{{DialNumber: "1"}, {DialNumber: "2"}, {DialNumber: "3"}},
+		id:          "http://www.google.com/m8/feeds/contacts/example.com/base/abc",
+	}
+
+	s := c.String()
+	for _, want := range []string{"id=abc", `name="Elizabeth Bennet"`, "emails=2", "phones=3"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expect %q in %s", want, s)
+		}
+	}
+	if strings.Contains(s, "liz@gmail.com") {
+		t.Errorf("expect no email address in %s", s)
+	}
+}
+
+func TestPage(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("start-index") != "51" || q.Get("max-results") != "25" || q.Get("strict") != "true" {
+			t.Errorf("expect start-index 51 and max-results 25, got %s", r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:openSearch='http://a9.com/-/spec/opensearch/1.1/'>
+  <openSearch:totalResults>120</openSearch:totalResults>
+  <link rel='next' type='application/atom+xml' href='https://www.google.com/m8/feeds/contacts/example.com/full?start-index=76'/>
+  %s
+  %s
+</feed>`, testEntry("abc", `"v1"`, ""), testEntry("def", `"v1"`, ""))
+	}))
+
+	page, total, err := s.Page(context.Background(), "", 3, 25)
+	if err != nil {
+		t.Fatalf("Page error: %v", err)
+	}
+	if total != 120 || len(page) != 2 || page[0].GetID() != "abc" || page[1].GetID() != "def" {
+		t.Fatalf("expect 2 contacts of 120, got %d of %d", len(page), total)
+	}
+
+	if _, _, err = s.Page(context.Background(), "", 0, 25); err == nil {
+		t.Fatalf("expect error for page 0")
+	}
+}
+
+func TestWithLenientDecode(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bad := strings.Replace(testEntry("bad", `"v1"`, ""), "2023-08-18T09:54:17.202Z", "yesterday", 1)
+		writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, ""), bad, testEntry("def", `"v1"`, ""))
+	})
+
+	if _, _, err := newTestService(t, h).ListContacts(context.Background(), "", ""); err == nil {
+		t.Fatalf("expect error for a corrupt entry without WithLenientDecode")
+	}
+
+	var logs strings.Builder
+	s := newTestService(t, h, WithLenientDecode(), WithLogger(log.New(&logs, "", 0)))
+	cs, _, err := s.ListContacts(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if len(cs) != 2 || cs[0].GetID() != "abc" || cs[1].GetID() != "def" {
+		t.Fatalf("expect contacts abc and def, got %v", cs)
+	}
+	if !strings.Contains(logs.String(), "skipped entry 1") {
+		t.Fatalf("expect the skipped entry logged, got %q", logs.String())
+	}
+}
+
+func TestListContactsMap(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start-index") == "3" {
+			// abc was edited after the first page was fetched.
+			edited := strings.Replace(testEntry("abc", `"v2"`, ""), "2023-08-18T09:54:17.202Z", "2023-09-01T00:00:00.000Z", 1)
+			writeFeed(w, `"feed"`, "", edited)
+			return
+		}
+		writeFeed(w, `"feed"`, "https://www.google.com/m8/feeds/contacts/example.com/full?start-index=3",
+			testEntry("abc", `"v1"`, ""), testEntry("def", `"v1"`, ""))
+	}))
+
+	m, _, err := s.ListContactsMap(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("ListContactsMap error: %v", err)
+	}
+	if len(m) != 2 || m["abc"] == nil || m["def"] == nil {
+		t.Fatalf("expect contacts abc and def, got %v", m)
+	}
+	if m["abc"].GetEtag() != `"v2"` {
+		t.Fatalf("expect the latest abc, got etag %s", m["abc"].GetEtag())
+	}
+}
+
+func TestContentType(t *testing.T) {
+	var got string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusCreated)
+		writeEntry(w, "abc", `"v1"`)
+	})
+
+	if _, err := newTestService(t, h).CreateContact(context.Background(), &ContactKind{}); err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	if got != "application/atom+xml; charset=UTF-8" {
+		t.Fatalf("expect the charset in the content type, got %q", got)
+	}
+
+	s := newTestService(t, h, WithContentType("application/atom+xml"))
+	if _, err := s.CreateContact(context.Background(), &ContactKind{}); err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	if got != "application/atom+xml" {
+		t.Fatalf("expect the content type overridden, got %q", got)
+	}
+}
+
+func TestListContactsEmptyFeed(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"empty"'>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+</feed>`)
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cs, st, err := s.ListContacts(ctx, "", "")
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if len(cs) != 0 {
+		t.Fatalf("expect no contacts, got %d", len(cs))
+	}
+	if st == nil || st.Etag != `"empty"` || st.Updated.IsZero() {
+		t.Fatalf("expect the status of the empty feed, got %+v", st)
+	}
+}
+
+func TestWithOrderBy(t *testing.T) {
+	var requests int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("orderby") != "lastmodified" {
+			t.Errorf("expect orderby lastmodified, got %s", r.URL.RawQuery)
+		}
+		writeFeed(w, `"feed"`, "")
+	}))
+
+	if _, _, err := s.ListContacts(context.Background(), "", "", WithOrderBy("lastmodified")); err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	_, _, err := s.ListContacts(context.Background(), "", "", WithOrderBy("name"))
+	if err == nil || !strings.Contains(err.Error(), `unsupported orderby "name"`) {
+		t.Fatalf("expect a client-side error for orderby name, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expect the invalid query not sent, got %d requests", requests)
+	}
+}
+
+func TestContactKindPlainStrings(t *testing.T) {
+	var c ContactKind
+	err := xml.Unmarshal([]byte(testEntry("abc", `"v1"`, `<gd:email rel='http://schemas.google.com/g/2005#work' primary='true' address='liz@gmail.com'/>
+  <gd:email rel='http://schemas.google.com/g/2005#home' address=' liz@example.org '/>
+  <gd:phoneNumber rel='http://schemas.google.com/g/2005#work' primary='true'>
+    (206)555-1212
+  </gd:phoneNumber>
+  <gd:phoneNumber rel='http://schemas.google.com/g/2005#mobile'>(206) 555-1213</gd:phoneNumber>`)), &c)
+	if err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+
+	if got := c.EmailAddresses(); fmt.Sprintf("%q", got) != `["liz@gmail.com" "liz@example.org"]` {
+		t.Fatalf("email addresses not match: %q", got)
+	}
+	if got := c.PhoneNumbers(); fmt.Sprintf("%q", got) != `["(206)555-1212" "(206) 555-1213"]` {
+		t.Fatalf("phone numbers not match: %q", got)
+	}
+}
+
+func TestWithLinkHeaderPaging(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start-index") == "2" {
+			writeFeed(w, `"feed"`, "", testEntry("def", `"v1"`, ""))
+			return
+		}
+		w.Header().Add("Link", `<https://www.google.com/m8/feeds/contacts/example.com/full>; rel="self", <https://www.google.com/m8/feeds/contacts/example.com/full?start-index=2>; rel="next"`)
+		writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, ""))
+	})
+
+	cs, _, err := newTestService(t, h).ListContacts(context.Background(), "", "")
+	if err != nil || len(cs) != 1 {
+		t.Fatalf("expect the Link header ignored by default, got %d contacts, %v", len(cs), err)
+	}
+
+	cs, _, err = newTestService(t, h, WithLinkHeaderPaging()).ListContacts(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if len(cs) != 2 || cs[1].GetID() != "def" {
+		t.Fatalf("expect the next page from the Link header, got %d contacts", len(cs))
+	}
+}
+
+func TestContactKindSetName(t *testing.T) {
+	c := ContactKind{Name: GDName{FullName: "Winnie"}}
+	c.SetName("Winston", "Spencer-Churchill", WithAdditionalName("Leonard"), WithNamePrefix("Sir"), WithNameSuffix("OG"))
+
+	want := GDName{
+		GivenName:      "Winston",
+		AdditionalName: "Leonard",
+		FamilyName:     "Spencer-Churchill",
+		Prefix:         "Sir",
+		Suffix:         "OG",
+		FullName:       "Sir Winston Leonard Spencer-Churchill OG",
+	}
+	if c.Name != want {
+		t.Fatalf("name not match: expect %+v, got %+v", want, c.Name)
+	}
+	if err := c.Name.Validate(); err != nil {
+		t.Fatalf("expect consistent names, got %v", err)
+	}
+}
+
+func TestCountContacts(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("max-results") != "1" {
+			t.Errorf("expect a single entry requested, got %s", r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:openSearch='http://a9.com/-/spec/opensearch/1.1/'>
+  <openSearch:totalResults>1234</openSearch:totalResults>
+  %s
+</feed>`, testEntry("abc", `"v1"`, ""))
+	}))
+
+	n, err := s.CountContacts(context.Background(), "", WithShowDeleted(true))
+	if err != nil {
+		t.Fatalf("CountContacts error: %v", err)
+	}
+	if n != 1234 {
+		t.Fatalf("expect 1234 contacts, got %d", n)
+	}
+}
+
+// benchmarkFeed returns a service serving a feed of 50 contacts.
+func benchmarkFeed(b *testing.B) *service {
+	entries := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		entries = append(entries, testEntry(fmt.Sprint(i), `"v1"`, ""))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFeed(w, `"feed"`, "", entries...)
+	}))
+	b.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		b.Fatalf("parse test server url: %v", err)
+	}
+	svc, err := NewService(&http.Client{Transport: rewriteTransport{u}}, "example.com", "")
+	if err != nil {
+		b.Fatalf("NewService error: %v", err)
+	}
+	return svc.(*service)
+}
+
+func BenchmarkListContacts(b *testing.B) {
+	s := benchmarkFeed(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := s.ListContacts(context.Background(), "", ""); err != nil {
+			b.Fatalf("ListContacts error: %v", err)
+		}
+	}
+}
+
+func BenchmarkListContactsInto(b *testing.B) {
+	s := benchmarkFeed(b)
+	b.ReportAllocs()
+	var dst []*ContactKind
+	for i := 0; i < b.N; i++ {
+		var err error
+		if dst, _, err = s.ListContactsInto(context.Background(), dst, "", ""); err != nil {
+			b.Fatalf("ListContactsInto error: %v", err)
+		}
+	}
+}
+
+func TestCreateContactMinimalPayload(t *testing.T) {
+	var body string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusCreated)
+		writeEntry(w, "abc", `"v1"`)
+	}))
+
+	if _, err := s.CreateContact(context.Background(), &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}}); err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	for _, want := range []string{"<gd:name>", "<gd:fullName>Elizabeth Bennet</gd:fullName>", `term="http://schemas.google.com/contact/2008#contact"`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expect %s in the request body, got %s", want, body)
+		}
+	}
+	for _, unwanted := range []string{
+		"<gd:email", "<gd:phoneNumber", "<gd:structuredPostalAddress", "<gd:im", "<gd:organization",
+		"<gd:extendedProperty", "<gContact:", "<content", "<id",
+	} {
+		if strings.Contains(body, unwanted) {
+			t.Fatalf("expect no %s in a name-only request body, got %s", unwanted, body)
+		}
+	}
+}
+
+func TestCreateContactInvalidRelLabel(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expect nothing sent, got %s %s", r.Method, r.URL.Path)
+	}))
+
+	p := &ContactKind{
+		Name:  GDName{FullName: "Elizabeth Bennet"},
+		Email: []GDEmail{{Address: "liz@gmail.com"}},
+	}
+	if _, err := s.CreateContact(context.Background(), p); err == nil || !strings.Contains(err.Error(), "gd:email 0") {
+		t.Fatalf("expect the email without rel nor label rejected, got %v", err)
+	}
+	if _, err := s.UpdateContact(context.Background(), "abc", "*", p); err == nil || !strings.Contains(err.Error(), "gd:email 0") {
+		t.Fatalf("expect the email without rel nor label rejected, got %v", err)
+	}
+}
+
+func TestCreateContactLocation(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Location", "http://www.google.com/m8/feeds/contacts/example.com/full/abc")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			if r.URL.Path != "/m8/feeds/contacts/example.com/full/abc" {
+				t.Errorf("expect the Location followed, got %s", r.URL.Path)
+			}
+			writeEntry(w, "abc", `"v1"`)
+		}
+	}))
+
+	ret, err := s.CreateContact(context.Background(), &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}})
+	if err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	if ret.GetID() != "abc" || ret.GetEtag() != `"v1"` {
+		t.Fatalf("expect the created contact abc, got %q %q", ret.GetID(), ret.GetEtag())
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	var status int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			writeEntry(w, "abc", `"v1"`)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprintln(w, "Version mismatch.")
+	}))
+	ctx := context.Background()
+	p := &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}}
+
+	status = http.StatusServiceUnavailable
+	_, err := s.CreateContact(ctx, p)
+	var ae *APIError
+	if !errors.As(err, &ae) {
+		t.Fatalf("expect an APIError, got %v", err)
+	}
+	if ae.StatusCode != http.StatusServiceUnavailable || ae.Status != "503 Service Unavailable" || ae.Body != "Version mismatch." {
+		t.Fatalf("unexpected APIError %+v", ae)
+	}
+	if errors.Is(err, ErrConflict) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect HTTP 503 to match no sentinel, got %v", err)
+	}
+
+	status = http.StatusConflict
+	if _, err = s.UpdateContact(ctx, "abc", `"v1"`, p); !errors.Is(err, ErrConflict) || !errors.As(err, &ae) {
+		t.Fatalf("expect an APIError matching ErrConflict, got %v", err)
+	}
+
+	status = http.StatusForbidden
+	if err = s.DeleteContact(ctx, "abc", `"v1"`); !errors.As(err, &ae) || ae.StatusCode != http.StatusForbidden {
+		t.Fatalf("expect an APIError for HTTP 403, got %v", err)
+	}
+	if want := "DeleteContact error: contacts: 403 Forbidden: Version mismatch."; err.Error() != want {
+		t.Fatalf("expect %q, got %q", want, err.Error())
+	}
+}
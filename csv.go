@@ -0,0 +1,78 @@
+package contacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvHeader is the header row of WriteCSV.
+var csvHeader = []string{"Name", "Primary Email", "Emails", "Primary Phone", "Organization", "City", "Region", "Country"}
+
+// WriteCSV writes contacts to w as CSV: a header row, then a row per
+// contact with its full name, primary email, all the emails joined by
+// semicolons, primary phone number, organization, and the city, region and
+// country of its primary postal address. Without a primary entry, the first
+// one is used. Nil contacts are skipped.
+func WriteCSV(w io.Writer, contacts []*ContactKind) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("WriteCSV error: %w", err)
+	}
+	for _, c := range contacts {
+		if c == nil {
+			continue
+		}
+		if err := cw.Write(csvRecord(c)); err != nil {
+			return fmt.Errorf("WriteCSV error: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("WriteCSV error: %w", err)
+	}
+	return nil
+}
+
+// csvRecord returns the row of c in WriteCSV.
+func csvRecord(c *ContactKind) []string {
+	name := c.Name.FullName
+	if strings.TrimSpace(name) == "" {
+		name = c.Name.composed()
+	}
+	emails := make([]string, 0, len(c.Email))
+	for _, m := range c.Email {
+		emails = append(emails, strings.TrimSpace(m.Address))
+	}
+	email := primaryEntry(c.Email, func(m GDEmail) bool { return m.Primary })
+	phone := primaryEntry(c.PhoneNumber, func(n GDPhoneNumber) bool { return n.Primary })
+	org := primaryEntry(c.Organization, func(org GDOrganization) bool { return org.Primary })
+	addr := primaryEntry(c.StructuredPostalAddress, func(a GDStructuredPostalAddress) bool { return a.Primary })
+
+	return []string{
+		name,
+		strings.TrimSpace(email.Address),
+		strings.Join(emails, ";"),
+		strings.TrimSpace(phone.DialNumber),
+		org.Name,
+		addr.City,
+		addr.Region,
+		addr.Country,
+	}
+}
+
+// primaryEntry returns the first entry that is primary, or the first entry
+// if none is. It returns the zero value without entries.
+func primaryEntry[T any](entries []T, primary func(T) bool) T {
+	var zero T
+	if len(entries) == 0 {
+		return zero
+	}
+	for _, e := range entries {
+		if primary(e) {
+			return e
+		}
+	}
+	return entries[0]
+}
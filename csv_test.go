@@ -0,0 +1,55 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	contacts := []*ContactKind{
+		{
+			Name: GDName{FullName: "Elizabeth Bennet"},
+			Email: []GDEmail{
+				{Address: "liz@example.org", Related: "http://schemas.google.com/g/2005#home"},
+				{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#work", Primary: true},
+			},
+			PhoneNumber: []GDPhoneNumber{
+				{Related: "http://schemas.google.com/g/2005#home", DialNumber: "(206)555-1213"},
+				{Related: "http://schemas.google.com/g/2005#work", Primary: true, DialNumber: " (206)555-1212 "},
+			},
+			Organization: []GDOrganization{{Name: "Longbourn, Inc."}},
+			StructuredPostalAddress: []GDStructuredPostalAddress{
+				{Related: "http://schemas.google.com/g/2005#home", City: "Meryton"},
+				{Related: "http://schemas.google.com/g/2005#work", Primary: true, City: "Mountain View", Region: "CA", Country: "US"},
+			},
+		},
+		nil,
+		{
+			// no primary email, the first one is used.
+			Name: GDName{GivenName: "Jane", FamilyName: "Bennet"},
+			Email: []GDEmail{
+				{Address: "jane@example.org", Related: "http://schemas.google.com/g/2005#home"},
+				{Address: "jane@gmail.com", Related: "http://schemas.google.com/g/2005#work"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, contacts); err != nil {
+		t.Fatalf("WriteCSV error: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("csv read error: %v", err)
+	}
+	want := [][]string{
+		{"Name", "Primary Email", "Emails", "Primary Phone", "Organization", "City", "Region", "Country"},
+		{"Elizabeth Bennet", "liz@gmail.com", "liz@example.org;liz@gmail.com", "(206)555-1212", "Longbourn, Inc.", "Mountain View", "CA", "US"},
+		{"Jane Bennet", "jane@example.org", "jane@example.org;jane@gmail.com", "", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("expect records %q, got %q", want, records)
+	}
+}
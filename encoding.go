@@ -3,6 +3,8 @@ package contacts
 import (
 	"encoding/xml"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
 	"time"
 )
@@ -15,14 +17,21 @@ func (c *ContactKind) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 		Etag     string   `xml:"etag,attr"`
 		Category struct {
 			Term string `xml:"term,attr"`
-		} `xml:"category"`
-		ID                      string                      `xml:"id"`
-		Updated                 time.Time                   `xml:"updated"`
-		Title                   string                      `xml:"title"`
-		Content                 string                      `xml:"content"`
+		} `xml:"http://www.w3.org/2005/Atom category"`
+		ID      string    `xml:"http://www.w3.org/2005/Atom id"`
+		Updated time.Time `xml:"http://www.w3.org/2005/Atom updated"`
+		Edited  time.Time `xml:"http://www.w3.org/2007/app edited"`
+		Control struct {
+			Draft string `xml:"http://www.w3.org/2007/app draft"`
+		} `xml:"http://www.w3.org/2007/app control"`
+		Title struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"http://www.w3.org/2005/Atom title"`
+		Content                 string                      `xml:"http://www.w3.org/2005/Atom content"`
 		Name                    GDName                      `xml:"http://schemas.google.com/g/2005 name"`
 		Email                   []GDEmail                   `xml:"http://schemas.google.com/g/2005 email"`
-		Deleted                 bool                        `xml:"http://schemas.google.com/g/2005 deleted"`
+		Deleted                 *struct{}                   `xml:"http://schemas.google.com/g/2005 deleted"`
 		PhoneNumber             []GDPhoneNumber             `xml:"http://schemas.google.com/g/2005 phoneNumber"`
 		StructuredPostalAddress []GDStructuredPostalAddress `xml:"http://schemas.google.com/g/2005 structuredPostalAddress"`
 		Link                    []Link                      `xml:"http://www.w3.org/2005/Atom link"`
@@ -32,6 +41,12 @@ func (c *ContactKind) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 		IM []GDIM `xml:"http://schemas.google.com/g/2005 im"`
 		// gd:organization*
 		Organization []GDOrganization `xml:"http://schemas.google.com/g/2005 organization"`
+		// gContact:userDefinedField*
+		UserDefinedField []GContactUserDefinedField `xml:"http://schemas.google.com/contact/2008 userDefinedField"`
+		// gContact:groupMembershipInfo*
+		GroupMembership []GroupMembershipInfo `xml:"http://schemas.google.com/contact/2008 groupMembershipInfo"`
+		// gContact:birthday?
+		Birthday *GContactBirthday `xml:"http://schemas.google.com/contact/2008 birthday"`
 	}
 
 	var o decodeContactKind
@@ -60,6 +75,12 @@ func (c *ContactKind) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 	c.PhoneNumber = append(c.PhoneNumber, o.PhoneNumber...)
 	c.StructuredPostalAddress = make([]GDStructuredPostalAddress, 0, len(o.StructuredPostalAddress))
 	c.StructuredPostalAddress = append(c.StructuredPostalAddress, o.StructuredPostalAddress...)
+	c.Organization = o.Organization
+	c.UserDefinedField = o.UserDefinedField
+	c.GroupMembership = o.GroupMembership
+	if o.Birthday != nil {
+		c.Birthday = *o.Birthday
+	}
 
 	for _, l := range o.Link {
 		switch l.Related {
@@ -72,10 +93,16 @@ func (c *ContactKind) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 		}
 	}
 
-	c.deleted = o.Deleted
+	// gd:deleted is an empty element, so its presence is the flag.
+	c.deleted = o.Deleted != nil
+	// app:draft is "yes" or "no", and absent for a published entry.
+	c.draft = strings.TrimSpace(o.Control.Draft) == "yes"
 	c.id = o.ID
 	c.updated = o.Updated
+	c.edited = o.Edited
 	c.content = o.Content
+	c.title = o.Title.Value
+	c.titleType = o.Title.Type
 	c.etag = o.Etag
 
 	c.ExtendedProperty = make(map[string]string, len(o.ExtendedProperty))
@@ -85,15 +112,176 @@ func (c *ContactKind) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 	return nil
 }
 
+// entryMeta is the server metadata of an entry of any kind.
+// Unlike ContactKind, it doesn't check the category term of the entry.
+type entryMeta struct {
+	etag     string
+	editLink string
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (m *entryMeta) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type decodeEntryMeta struct {
+		Etag string `xml:"etag,attr"`
+		Link []Link `xml:"http://www.w3.org/2005/Atom link"`
+	}
+
+	var o decodeEntryMeta
+	if err := d.DecodeElement(&o, &start); err != nil {
+		return err
+	}
+	for _, l := range o.Link {
+		if l.Related == "edit" {
+			m.editLink = l.Href
+		}
+	}
+	m.etag = o.Etag
+	return nil
+}
+
+// feedEntry is a contact entry of a feed. An entry that fails to decode
+// keeps the error instead of failing the decoding of the whole feed.
+type feedEntry struct {
+	contact ContactKind
+	err     error
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (f *feedEntry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	toks, err := captureElement(d, start)
+	if err != nil {
+		// the feed itself is malformed, so the following entries are lost too.
+		return err
+	}
+	f.err = decodeCaptured(toks, &f.contact)
+	return nil
+}
+
 // MarshalXML implements xml.Marshaler.
 // It hides unnecessory fields when sending a request to server.
 func (c ContactKind) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return c.marshalEntry(e, start, entryExtras{}, encodeOptions{})
+}
+
+// WriteAtomFile writes c as a standalone atom entry document, with the xml
+// declaration and indented elements, that can be POSTed to the contacts feed as is.
+func (c ContactKind) WriteAtomFile(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := c.marshalEntry(e, xml.StartElement{}, entryExtras{Xmlns: nsAtom}, encodeOptions{}); err != nil {
+		return err
+	}
+	if err := e.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// encodeOptions controls how the service encodes contacts in requests.
+type encodeOptions struct {
+	// singlePrimaryPhone keeps only the first primary phone number.
+	singlePrimaryPhone bool
+	// explicitPrimaryFalse sends primary="false" on the emails demoted by SetPrimaryEmail.
+	explicitPrimaryFalse bool
+}
+
+// apply returns c as it's sent with the options. c itself is not modified.
+func (opts encodeOptions) apply(c ContactKind) ContactKind {
+	if opts.singlePrimaryPhone {
+		c.PhoneNumber = cloneSlice(c.PhoneNumber)
+		primary := false
+		for i := range c.PhoneNumber {
+			if primary {
+				c.PhoneNumber[i].Primary = false
+			}
+			primary = primary || c.PhoneNumber[i].Primary
+		}
+	}
+	if !opts.explicitPrimaryFalse {
+		c.Email = cloneSlice(c.Email)
+		for i := range c.Email {
+			c.Email[i].demoted = false
+		}
+	}
+	return c
+}
+
+// payload encodes a contact with the service's encode options.
+type payload struct {
+	contact *ContactKind
+	opts    encodeOptions
+}
+
+// MarshalXML implements xml.Marshaler.
+func (p payload) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return p.contact.marshalEntry(e, start, entryExtras{}, p.opts)
+}
+
+// XML namespaces of the contact entries.
+const (
+	nsAtom     = "http://www.w3.org/2005/Atom"
+	nsGD       = "http://schemas.google.com/g/2005"
+	nsGContact = "http://schemas.google.com/contact/2008"
+	nsBatch    = "http://schemas.google.com/gdata/batch"
+)
+
+// entryNamespaces are the namespaces a contact entry may declare, in the
+// order they are declared. used reports whether a contact needs it.
+var entryNamespaces = []struct {
+	prefix string
+	uri    string
+	used   func(ContactKind) bool
+}{
+	{"atom", nsAtom, func(ContactKind) bool { return true }},
+	{"gd", nsGD, func(ContactKind) bool { return true }},
+	{"gContact", nsGContact, ContactKind.usesGContact},
+}
+
+// namespaceAttrs returns the xmlns attributes of the namespaces c uses.
+func (c ContactKind) namespaceAttrs() []xml.Attr {
+	attrs := make([]xml.Attr, 0, len(entryNamespaces)+1)
+	for _, ns := range entryNamespaces {
+		if ns.used(c) {
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Space: "", Local: "xmlns:" + ns.prefix}, Value: ns.uri})
+		}
+	}
+	return attrs
+}
+
+// usesGContact reports whether c has data in gContact elements.
+func (c ContactKind) usesGContact() bool {
+	return len(c.UserDefinedField) > 0 || len(c.GroupMembership) > 0 || !c.Birthday.IsZero()
+}
+
+// entryExtras are the entry elements only sent in some requests, such as
+// the id and batch elements of a batch feed entry.
+type entryExtras struct {
+	// Xmlns declares the default namespace, for entries written standalone.
+	Xmlns          string
+	ID             string
+	Etag           string
+	BatchID        string
+	BatchOperation string
+}
+
+// batchOperation is the batch:operation element.
+type batchOperation struct {
+	Type string `xml:"type,attr"`
+}
+
+func (c ContactKind) marshalEntry(e *xml.Encoder, start xml.StartElement, extras entryExtras, opts encodeOptions) error {
+	c = opts.apply(c)
 	type encodeContactKind struct {
+		ID                      string                      `xml:"id,omitempty"`
 		Name                    GDName                      `xml:"gd:name"`
 		Email                   []GDEmail                   `xml:"gd:email,omitempty"`
 		PhoneNumber             []GDPhoneNumber             `xml:"gd:phoneNumber,omitempty"`
 		StructuredPostalAddress []GDStructuredPostalAddress `xml:"gd:structuredPostalAddress,omitempty"`
-		Content                 string                      `xml:"content"`
+		Content                 string                      `xml:"content,omitempty"`
 		// atom:category
 		Category struct {
 			Scheme string `xml:"scheme,attr"`
@@ -104,7 +292,17 @@ func (c ContactKind) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		ExtendedProperty []GDExtendedProperty `xml:"gd:extendedProperty,omitempty"`
 		IM               []GDIM               `xml:"gd:im,omitempty"`
 
-		// Organization []GDOrganization `xml:"gd:organization"`
+		Organization []GDOrganization `xml:"gd:organization,omitempty"`
+
+		UserDefinedField []GContactUserDefinedField `xml:"gContact:userDefinedField,omitempty"`
+		GroupMembership  []GroupMembershipInfo      `xml:"gContact:groupMembershipInfo,omitempty"`
+		Birthday         *GContactBirthday          `xml:"gContact:birthday,omitempty"`
+
+		BatchID        string          `xml:"batch:id,omitempty"`
+		BatchOperation *batchOperation `xml:"batch:operation,omitempty"`
+
+		// gd:deleted is never sent, even for a contact read with
+		// WithShowDeleted: a PUT can't delete or restore a contact.
 	}
 
 	type category struct {
@@ -118,6 +316,11 @@ func (c ContactKind) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	}
 
 	var o encodeContactKind
+	o.ID = extras.ID
+	o.BatchID = extras.BatchID
+	if extras.BatchOperation != "" {
+		o.BatchOperation = &batchOperation{Type: extras.BatchOperation}
+	}
 	o.Content = c.content
 	o.Name = GDName{
 		GivenName:      c.Name.GivenName,
@@ -129,26 +332,31 @@ func (c ContactKind) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	}
 	o.Email = make([]GDEmail, 0, len(c.Email))
 	o.Email = append(o.Email, c.Email...)
-	o.PhoneNumber = make([]GDPhoneNumber, len(c.PhoneNumber))
+	o.PhoneNumber = make([]GDPhoneNumber, 0, len(c.PhoneNumber))
 	o.PhoneNumber = append(o.PhoneNumber, c.PhoneNumber...)
-	o.StructuredPostalAddress = make([]GDStructuredPostalAddress, len(c.StructuredPostalAddress))
+	o.StructuredPostalAddress = make([]GDStructuredPostalAddress, 0, len(c.StructuredPostalAddress))
 	o.StructuredPostalAddress = append(o.StructuredPostalAddress, c.StructuredPostalAddress...)
 
-	o.IM = make([]GDIM, len(c.IM))
+	o.IM = make([]GDIM, 0, len(c.IM))
 	o.IM = append(o.IM, c.IM...)
 
-	o.ExtendedProperty = make([]GDExtendedProperty, len(c.ExtendedProperty))
-	for k, v := range c.ExtendedProperty {
-		o.ExtendedProperty = append(o.ExtendedProperty, GDExtendedProperty{
-			Name:  k,
-			Value: v,
-		})
+	o.Organization = c.Organization
+	o.UserDefinedField = c.UserDefinedField
+	o.GroupMembership = c.GroupMembership
+	if !c.Birthday.IsZero() {
+		o.Birthday = &c.Birthday
 	}
 
+	o.ExtendedProperty = extendedProperties(c.ExtendedProperty)
+
 	start.Name = xml.Name{Space: "", Local: "entry"}
-	attrs := make([]xml.Attr, 0, 2)
-	attrs = append(attrs, xml.Attr{Name: xml.Name{Space: "", Local: "xmlns:atom"}, Value: "http://www.w3.org/2005/Atom"})
-	attrs = append(attrs, xml.Attr{Name: xml.Name{Space: "", Local: "xmlns:gd"}, Value: "http://schemas.google.com/g/2005"})
+	attrs := c.namespaceAttrs()
+	if extras.Xmlns != "" {
+		attrs = append([]xml.Attr{{Name: xml.Name{Space: "", Local: "xmlns"}, Value: extras.Xmlns}}, attrs...)
+	}
+	if extras.Etag != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Space: "", Local: "gd:etag"}, Value: extras.Etag})
+	}
 	start.Attr = attrs
 	o.Category = cat
 
@@ -157,12 +365,43 @@ func (c ContactKind) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 
 // GDName allows storing person's name in a structured way. Consists of given name, additional name, family name, prefix, suffix and full name.
 type GDName struct {
-	GivenName      string
-	AdditionalName string
-	FamilyName     string
-	Prefix         string
-	Suffix         string
-	FullName       string
+	GivenName      string `json:"givenName,omitempty"`
+	AdditionalName string `json:"additionalName,omitempty"`
+	FamilyName     string `json:"familyName,omitempty"`
+	Prefix         string `json:"prefix,omitempty"`
+	Suffix         string `json:"suffix,omitempty"`
+	FullName       string `json:"fullName,omitempty"`
+}
+
+// Compose fills FullName from the name components when FullName is empty.
+// The components are joined in the order prefix, given, additional, family, suffix.
+func (n *GDName) Compose() {
+	if strings.TrimSpace(n.FullName) == "" {
+		n.FullName = n.composed()
+	}
+}
+
+// Validate reports whether FullName disagrees with the name components.
+// The server keeps both as sent, so a mismatch usually is a caller mistake.
+// It returns nil if either side is empty.
+func (n GDName) Validate() error {
+	full := strings.Join(strings.Fields(n.FullName), " ")
+	composed := n.composed()
+	if full == "" || composed == "" || full == composed {
+		return nil
+	}
+	return fmt.Errorf("name mismatch: full name %q, components compose %q", full, composed)
+}
+
+// composed joins the non-empty name components with single spaces.
+func (n GDName) composed() string {
+	parts := make([]string, 0, 5)
+	for _, p := range []string{n.Prefix, n.GivenName, n.AdditionalName, n.FamilyName, n.Suffix} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
 }
 
 // UnmarshalXML implements xml.Unmarshaler.
@@ -218,18 +457,21 @@ func (n GDName) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 }
 
 // GDEmail saves an email address.
-// It's "rel" field has 3 possible values.
+// It's "rel" field has 3 possible values, the EmailRel constants.
 // - http://schemas.google.com/g/2005#home
 // - http://schemas.google.com/g/2005#other
 // - http://schemas.google.com/g/2005#work
 // If it uses "http://schemas.google.com/g/2005#other" in the "rel" field,
 // you should use "label" to express the real relation of the entity.
 type GDEmail struct {
-	Address     string `xml:"address,attr"`
-	Related     string `xml:"rel,attr,omitempty"`
-	Label       string `xml:"label,attr,omitempty"`
-	Primary     bool   `xml:"primary,attr,omitempty"`
-	DisplayName string `xml:"displayName,attr,omitempty"`
+	Address     string `xml:"address,attr" json:"address"`
+	Related     string `xml:"rel,attr,omitempty" json:"rel,omitempty"`
+	Label       string `xml:"label,attr,omitempty" json:"label,omitempty"`
+	Primary     bool   `xml:"primary,attr,omitempty" json:"primary,omitempty"`
+	DisplayName string `xml:"displayName,attr,omitempty" json:"displayName,omitempty"`
+
+	// demoted is set by SetPrimaryEmail on the email it made non-primary.
+	demoted bool
 }
 
 // MarshalXML implements xml.Marshaler.
@@ -243,17 +485,29 @@ func (m GDEmail) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		Address     string `xml:"address,attr"`
 		Related     string `xml:"rel,attr,omitempty"`
 		Label       string `xml:"label,attr,omitempty"`
-		Primary     bool   `xml:"primary,attr,omitempty"`
+		Primary     string `xml:"primary,attr,omitempty"`
 		DisplayName string `xml:"displayName,attr,omitempty"`
 	}
 
-	var obj encodedGDEmail = encodedGDEmail(m)
+	obj := encodedGDEmail{
+		Address:     m.Address,
+		Related:     m.Related,
+		Label:       m.Label,
+		DisplayName: m.DisplayName,
+	}
+	switch {
+	case m.Primary:
+		obj.Primary = "true"
+	case m.demoted:
+		// encodeOptions.apply keeps the flag only with explicitPrimaryFalse.
+		obj.Primary = "false"
+	}
 
 	return e.EncodeElement(obj, start)
 }
 
 // GDPhoneNumber saves a phone number.
-// It's "rel" field has many possible values.
+// It's "rel" field has many possible values, the PhoneRel constants.
 // - http://schemas.google.com/g/2005#assistant
 // - http://schemas.google.com/g/2005#callback
 // - http://schemas.google.com/g/2005#car
@@ -277,11 +531,11 @@ func (m GDEmail) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 // If "rel" equals to "http://schemas.google.com/g/2005#other",
 // use "label" to express the real relation.
 type GDPhoneNumber struct {
-	Related    string `xml:"rel,attr,omitempty"`
-	Label      string `xml:"label,attr,omitempty"`
-	URI        string `xml:"uri,attr,omitempty"`
-	Primary    bool   `xml:"primary,attr,omitempty"`
-	DialNumber string `xml:",chardata"` // it may contain white spaces.
+	Related    string `xml:"rel,attr,omitempty" json:"rel,omitempty"`
+	Label      string `xml:"label,attr,omitempty" json:"label,omitempty"`
+	URI        string `xml:"uri,attr,omitempty" json:"uri,omitempty"`
+	Primary    bool   `xml:"primary,attr,omitempty" json:"primary,omitempty"`
+	DialNumber string `xml:",chardata" json:"number,omitempty"` // it may contain white spaces.
 }
 
 // UnmarshalXML implements xml.Unmarshaler.
@@ -309,6 +563,37 @@ func (n *GDPhoneNumber) UnmarshalXML(d *xml.Decoder, start xml.StartElement) err
 	return nil
 }
 
+// Normalized returns n with the white space of its dial number trimmed and
+// each inner run of white space collapsed into a single space. Other
+// characters, such as '+', parentheses, the extension and non-ASCII digits,
+// are kept as they are.
+func (n GDPhoneNumber) Normalized() GDPhoneNumber {
+	n.DialNumber = strings.Join(strings.Fields(n.DialNumber), " ")
+	return n
+}
+
+// Extension returns the extension of the phone number, taken from the
+// ";ext=" parameter of the tel URI, or else from a trailing "ext." in the
+// dial number, e.g. "(425) 555-8080 ext. 52585". It returns empty if there's none.
+func (n GDPhoneNumber) Extension() string {
+	for _, param := range strings.Split(n.URI, ";")[1:] {
+		if k, v, ok := strings.Cut(param, "="); ok && strings.EqualFold(k, "ext") {
+			return v
+		}
+	}
+
+	dial := strings.TrimSpace(n.DialNumber)
+	idx := strings.LastIndex(strings.ToLower(dial), "ext")
+	if idx < 0 {
+		return ""
+	}
+	ext := strings.TrimSpace(strings.TrimPrefix(dial[idx+len("ext"):], "."))
+	if ext == "" || strings.Trim(ext, "0123456789") != "" {
+		return ""
+	}
+	return ext
+}
+
 // MarshalXML implements xml.Marshaler.
 func (n GDPhoneNumber) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	start.Name = xml.Name{
@@ -328,19 +613,21 @@ func (n GDPhoneNumber) MarshalXML(e *xml.Encoder, start xml.StartElement) error
 }
 
 // GDIM saves an instant message account.
-// It's "rel" field has the following possible values.
+// It's "rel" field has the following possible values, the IMRel constants.
 // - http://schemas.google.com/g/2005#home
 // - http://schemas.google.com/g/2005#netmeeting
 // - http://schemas.google.com/g/2005#other
 // - http://schemas.google.com/g/2005#work
 // If the "rel" field equals to "http://schemas.google.com/g/2005#other",
 // it uses "label" to express the real relation.
+// Address is required; Validate reports an IM without it.
+// Protocol is one of the IMProtocol constants.
 type GDIM struct {
-	Address  string `xml:"address,attr"`
-	Label    string `xml:"label,attr,omitempty"`
-	Related  string `xml:"rel,attr,omitempty"`
-	Protocol string `xml:"protocol,attr,omitempty"`
-	Primary  bool   `xml:"primary,attr,omitempty"`
+	Address  string `xml:"address,attr" json:"address"`
+	Label    string `xml:"label,attr,omitempty" json:"label,omitempty"`
+	Related  string `xml:"rel,attr,omitempty" json:"rel,omitempty"`
+	Protocol string `xml:"protocol,attr,omitempty" json:"protocol,omitempty"`
+	Primary  bool   `xml:"primary,attr,omitempty" json:"primary,omitempty"`
 }
 
 // MarshalXML implements xml.Marshaler.
@@ -361,12 +648,39 @@ func (im GDIM) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 }
 
 // GDOrganization saves an organization occupation of the contact person.
-// NOT IMPLEMENTED YET
+// It's "rel" field has the following possible values
+// - http://schemas.google.com/g/2005#work
+// - http://schemas.google.com/g/2005#other
 type GDOrganization struct {
+	Related        string `xml:"rel,attr,omitempty" json:"rel,omitempty"`
+	Label          string `xml:"label,attr,omitempty" json:"label,omitempty"`
+	Primary        bool   `xml:"primary,attr,omitempty" json:"primary,omitempty"`
+	Name           string `xml:"http://schemas.google.com/g/2005 orgName,omitempty" json:"name,omitempty"`
+	Title          string `xml:"http://schemas.google.com/g/2005 orgTitle,omitempty" json:"title,omitempty"`
+	Department     string `xml:"http://schemas.google.com/g/2005 orgDepartment,omitempty" json:"department,omitempty"`
+	JobDescription string `xml:"http://schemas.google.com/g/2005 orgJobDescription,omitempty" json:"jobDescription,omitempty"`
+	Symbol         string `xml:"http://schemas.google.com/g/2005 orgSymbol,omitempty" json:"symbol,omitempty"`
+}
+
+// MarshalXML implements xml.Marshaler.
+func (org GDOrganization) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: "", Local: "gd:organization"}
+	type encodeGDOrganization struct {
+		Related        string `xml:"rel,attr,omitempty"`
+		Label          string `xml:"label,attr,omitempty"`
+		Primary        bool   `xml:"primary,attr,omitempty"`
+		Name           string `xml:"gd:orgName,omitempty"`
+		Title          string `xml:"gd:orgTitle,omitempty"`
+		Department     string `xml:"gd:orgDepartment,omitempty"`
+		JobDescription string `xml:"gd:orgJobDescription,omitempty"`
+		Symbol         string `xml:"gd:orgSymbol,omitempty"`
+	}
+	obj := encodeGDOrganization(org)
+	return e.EncodeElement(obj, start)
 }
 
 // GDStructuredPostalAddress saves postal address.
-// It's "rel" field has the following possible values
+// It's "rel" field has the following possible values, the AddressRel constants.
 // - http://schemas.google.com/g/2005#work
 // - http://schemas.google.com/g/2005#home
 // - http://schemas.google.com/g/2005#other
@@ -381,23 +695,23 @@ type GDOrganization struct {
 // http://schemas.google.com/g/2005#general
 // http://schemas.google.com/g/2005#local
 type GDStructuredPostalAddress struct {
-	Related   string
-	MailClass string
-	Usage     string
-	Label     string
-	Primary   bool
-
-	Agent            string
-	HouseName        string
-	Pobox            string
-	Neighborhood     string
-	City             string
-	Street           string
-	Region           string
-	SubRegion        string
-	PostCode         string
-	Country          string
-	FormattedAddress string
+	Related   string `json:"rel,omitempty"`
+	MailClass string `json:"mailClass,omitempty"`
+	Usage     string `json:"usage,omitempty"`
+	Label     string `json:"label,omitempty"`
+	Primary   bool   `json:"primary,omitempty"`
+
+	Agent            string `json:"agent,omitempty"`
+	HouseName        string `json:"houseName,omitempty"`
+	Pobox            string `json:"pobox,omitempty"`
+	Neighborhood     string `json:"neighborhood,omitempty"`
+	City             string `json:"city,omitempty"`
+	Street           string `json:"street,omitempty"`
+	Region           string `json:"region,omitempty"`
+	SubRegion        string `json:"subRegion,omitempty"`
+	PostCode         string `json:"postcode,omitempty"`
+	Country          string `json:"country,omitempty"`
+	FormattedAddress string `json:"formattedAddress,omitempty"`
 }
 
 // UnmarshalXML implements xml.Unmarshaler.
@@ -427,7 +741,7 @@ func (a *GDStructuredPostalAddress) UnmarshalXML(d *xml.Decoder, start xml.Start
 		return err
 	}
 
-	a.Region = o.Related
+	a.Related = o.Related
 	a.MailClass = o.MailClass
 	a.Usage = o.Usage
 	a.Label = o.Label
@@ -499,6 +813,21 @@ type GDExtendedProperty struct {
 	Realm string `xml:"realm,attr,omitempty"`
 }
 
+// extendedProperties returns the elements of the extended properties m,
+// sorted by name, so that the output doesn't depend on the map order.
+func extendedProperties(m map[string]string) []GDExtendedProperty {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	ret := make([]GDExtendedProperty, 0, len(m))
+	for _, k := range names {
+		ret = append(ret, GDExtendedProperty{Name: k, Value: m[k]})
+	}
+	return ret
+}
+
 // MarshalXML implements xml.Marshaler.
 func (p GDExtendedProperty) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	start.Name = xml.Name{Space: "", Local: "gd:extendedProperty"}
@@ -511,9 +840,172 @@ func (p GDExtendedProperty) MarshalXML(e *xml.Encoder, start xml.StartElement) e
 	return e.EncodeElement(obj, start)
 }
 
+// GContactUserDefinedField is a custom key-value field shown in the contact's
+// user interface. Unlike the extended properties, the fields keep their order.
+type GContactUserDefinedField struct {
+	Key   string `xml:"key,attr" json:"key,omitempty"`
+	Value string `xml:"value,attr" json:"value,omitempty"`
+}
+
+// MarshalXML implements xml.Marshaler.
+func (f GContactUserDefinedField) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: "", Local: "gContact:userDefinedField"}
+	type encodeGContactUserDefinedField struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:"value,attr"`
+	}
+	obj := encodeGContactUserDefinedField(f)
+	return e.EncodeElement(obj, start)
+}
+
+// GroupMembershipInfo is a membership of the contact in a group.
+// Href is the full ID of the group, see GroupKind.GetFullID.
+type GroupMembershipInfo struct {
+	Href    string `xml:"href,attr" json:"href"`
+	Deleted bool   `xml:"deleted,attr,omitempty" json:"deleted,omitempty"`
+}
+
+// MarshalXML implements xml.Marshaler.
+func (m GroupMembershipInfo) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: "", Local: "gContact:groupMembershipInfo"}
+	type encodeGroupMembershipInfo struct {
+		Href    string `xml:"href,attr"`
+		Deleted bool   `xml:"deleted,attr,omitempty"`
+	}
+	obj := encodeGroupMembershipInfo(m)
+	return e.EncodeElement(obj, start)
+}
+
+// GContactBirthday is the birthday of the contact, gContact:birthday.
+// The year may be unknown: Year is 0 then, and the birthday is written in
+// the year-less form --MM-DD. The zero value is no birthday.
+//
+// A when attribute that can't be parsed doesn't fail the decoding of the
+// contact: the fields are left zero, the value is kept as is and written
+// back, and Validate reports it.
+type GContactBirthday struct {
+	Year  int
+	Month time.Month
+	Day   int
+
+	invalid string // the when attribute that failed to parse
+}
+
+// ParseBirthday parses the when attribute of gContact:birthday, either
+// YYYY-MM-DD or the year-less --MM-DD.
+func ParseBirthday(when string) (GContactBirthday, error) {
+	layout, value := "2006-01-02", when
+	if strings.HasPrefix(when, "--") {
+		layout, value = "01-02", when[2:]
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return GContactBirthday{}, fmt.Errorf("invalid birthday %q: %w", when, err)
+	}
+	return GContactBirthday{Year: t.Year(), Month: t.Month(), Day: t.Day()}, nil
+}
+
+// IsZero reports whether b is no birthday.
+func (b GContactBirthday) IsZero() bool { return b == GContactBirthday{} }
+
+// String returns b in the form of the when attribute, e.g. 1985-06-01 or
+// --06-01 without a year, or the value as it was received if it's invalid.
+func (b GContactBirthday) String() string {
+	if b.invalid != "" {
+		return b.invalid
+	}
+	if b.Year == 0 {
+		return fmt.Sprintf("--%02d-%02d", int(b.Month), b.Day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", b.Year, int(b.Month), b.Day)
+}
+
+// MarshalText implements encoding.TextMarshaler, in the form of String.
+func (b GContactBirthday) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseBirthday.
+// Like UnmarshalXML, it keeps an invalid value instead of failing.
+func (b *GContactBirthday) UnmarshalText(text []byte) error {
+	*b = parseBirthdayLenient(string(text))
+	return nil
+}
+
+// MarshalXML implements xml.Marshaler.
+func (b GContactBirthday) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Space: "", Local: "gContact:birthday"}
+	type encodeGContactBirthday struct {
+		When string `xml:"when,attr"`
+	}
+	return e.EncodeElement(encodeGContactBirthday{When: b.String()}, start)
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (b *GContactBirthday) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type decodeGContactBirthday struct {
+		When string `xml:"when,attr"`
+	}
+	var o decodeGContactBirthday
+	if err := d.DecodeElement(&o, &start); err != nil {
+		return err
+	}
+	// one malformed birthday mustn't fail the whole feed.
+	*b = parseBirthdayLenient(o.When)
+	return nil
+}
+
+// parseBirthdayLenient parses when like ParseBirthday, keeping it as an
+// invalid birthday if it fails.
+func parseBirthdayLenient(when string) GContactBirthday {
+	when = strings.TrimSpace(when)
+	v, err := ParseBirthday(when)
+	if err != nil {
+		return GContactBirthday{invalid: when}
+	}
+	return v
+}
+
 // Link saves link tags in a ContactKind
 type Link struct {
 	Related string `xml:"rel,attr"`
 	Type    string `xml:"type,attr"`
 	Href    string `xml:"href,attr"`
 }
+
+// captureElement reads the element opened by start up to its matching end
+// element, so that the element can be decoded more than once.
+func captureElement(d *xml.Decoder, start xml.StartElement) ([]xml.Token, error) {
+	toks := []xml.Token{start.Copy()}
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		toks = append(toks, xml.CopyToken(tok))
+	}
+	return toks, nil
+}
+
+// tokenReplay is a xml.TokenReader over captured tokens.
+type tokenReplay struct{ toks []xml.Token }
+
+func (r *tokenReplay) Token() (xml.Token, error) {
+	if len(r.toks) == 0 {
+		return nil, io.EOF
+	}
+	tok := r.toks[0]
+	r.toks = r.toks[1:]
+	return tok, nil
+}
+
+// decodeCaptured decodes an element captured by captureElement into v.
+func decodeCaptured(toks []xml.Token, v interface{}) error {
+	return xml.NewTokenDecoder(&tokenReplay{toks: toks}).Decode(v)
+}
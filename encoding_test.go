@@ -1,10 +1,19 @@
 package contacts
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGDName(t *testing.T) {
@@ -87,6 +96,48 @@ func TestGDPhoneNumber(t *testing.T) {
 	}
 }
 
+func TestGDPhoneNumberNormalized(t *testing.T) {
+	for _, tc := range []struct{ in, expect string }{
+		{"  +44 (0)20\t 7946\n   0958  ext. 12 ", "+44 (0)20 7946 0958 ext. 12"},
+		{"+٩٧١\u00a0 ٤  ٣٦٦ ٢٠٠٠", "+٩٧١ ٤ ٣٦٦ ٢٠٠٠"},
+	} {
+		n := GDPhoneNumber{Related: "http://schemas.google.com/g/2005#work", DialNumber: tc.in}.Normalized()
+		if n.DialNumber != tc.expect {
+			t.Errorf("%q: expect %q, got %q", tc.in, tc.expect, n.DialNumber)
+		}
+
+		b, err := xml.Marshal(n)
+		if err != nil {
+			t.Fatalf("xml marshal error: %v", err)
+		}
+		var got GDPhoneNumber
+		if err = xml.Unmarshal(b, &got); err != nil {
+			t.Fatalf("xml unmarshal error: %v", err)
+		}
+		if got != n {
+			t.Errorf("%q: expect %+v after a round trip, got %+v", tc.in, n, got)
+		}
+	}
+}
+
+func TestGDPhoneNumberExtension(t *testing.T) {
+	for _, tc := range []struct {
+		n      GDPhoneNumber
+		expect string
+	}{
+		{GDPhoneNumber{URI: "tel:+1-425-555-8080;ext=52585", DialNumber: "(425) 555-8080"}, "52585"},
+		{GDPhoneNumber{URI: "tel:+1-425-555-8080;phone-context=example.com;ext=7", DialNumber: "(425) 555-8080"}, "7"},
+		{GDPhoneNumber{DialNumber: "(425) 555-8080 ext. 52585"}, "52585"},
+		{GDPhoneNumber{DialNumber: "(425) 555-8080 EXT 12"}, "12"},
+		{GDPhoneNumber{URI: "tel:+1-425-555-8080", DialNumber: "(425) 555-8080"}, ""},
+		{GDPhoneNumber{DialNumber: "ext. office"}, ""},
+	} {
+		if got := tc.n.Extension(); got != tc.expect {
+			t.Errorf("%+v: expect extension %q, got %q", tc.n, tc.expect, got)
+		}
+	}
+}
+
 func TestGDIM(t *testing.T) {
 	bs := []byte(`<gd:im protocol="http://schemas.google.com/g/2005#MSN" address="foo@bar.msn.com" rel="http://schemas.google.com/g/2005#home" primary="true"/>`)
 	var im GDIM
@@ -146,8 +197,23 @@ func TestGDPostalAddress(t *testing.T) {
 	}
 }
 
-func TestContactKind(t *testing.T) {
-	bs := []byte(`<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005'>
+func TestGDPostalAddressRelated(t *testing.T) {
+	bs := []byte(`<gd:structuredPostalAddress xmlns:gd='http://schemas.google.com/g/2005' rel='http://schemas.google.com/g/2005#work'>
+  <gd:city>Mountain View</gd:city>
+  <gd:region>CA</gd:region>
+</gd:structuredPostalAddress>`)
+
+	var a GDStructuredPostalAddress
+	if err := xml.Unmarshal(bs, &a); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if a.Related != "http://schemas.google.com/g/2005#work" || a.Region != "CA" {
+		t.Fatalf("expect rel work and region CA, got %q and %q", a.Related, a.Region)
+	}
+}
+
+// bennetEntry is the contact entry of the API documentation.
+const bennetEntry = `<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005'>
   <category scheme='http://schemas.google.com/g/2005#kind' 
       term='http://schemas.google.com/contact/2008#contact'/>
   <title>Elizabeth Bennet</title>
@@ -180,7 +246,10 @@ func TestContactKind(t *testing.T) {
 		<gd:region>CA</gd:region>
 		<gd:postcode>94041</gd:postcode>
   </gd:structuredPostalAddress>
-</entry>`)
+</entry>`
+
+func TestContactKind(t *testing.T) {
+	bs := []byte(bennetEntry)
 
 	var c ContactKind
 	if err := xml.Unmarshal(bs, &c); err != nil {
@@ -244,3 +313,506 @@ func TestContactKind(t *testing.T) {
 	}
 
 }
+
+func TestGDNameCompose(t *testing.T) {
+	n := GDName{Prefix: "Sir", GivenName: "Winston", AdditionalName: "Leonard", FamilyName: "Spencer-Churchill", Suffix: "OG"}
+	n.Compose()
+	if n.FullName != "Sir Winston Leonard Spencer-Churchill OG" {
+		t.Fatalf("compose error: got %q", n.FullName)
+	}
+
+	n = GDName{GivenName: "Elizabeth", FamilyName: "Bennet", FullName: "Liz Bennet"}
+	n.Compose()
+	if n.FullName != "Liz Bennet" {
+		t.Fatalf("compose error: should not overwrite full name, got %q", n.FullName)
+	}
+}
+
+func TestGDNameValidate(t *testing.T) {
+	n := GDName{GivenName: "Elizabeth", FamilyName: "Bennet", FullName: " Elizabeth  Bennet "}
+	if err := n.Validate(); err != nil {
+		t.Fatalf("validate error: %v", err)
+	}
+
+	n.FullName = "Jane Bennet"
+	if err := n.Validate(); err == nil {
+		t.Fatalf("validate error: expect mismatch warning")
+	}
+
+	if err := (GDName{FullName: "Jane Bennet"}).Validate(); err != nil {
+		t.Fatalf("validate error: full name only should pass, got %v", err)
+	}
+}
+
+func TestContactKindEdited(t *testing.T) {
+	bs := []byte(`<entry xmlns='http://www.w3.org/2005/Atom' xmlns:app='http://www.w3.org/2007/app'>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+  <app:edited>2023-08-18T09:50:00.000Z</app:edited>
+</entry>`)
+
+	var c ContactKind
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if want := time.Date(2023, 8, 18, 9, 50, 0, 0, time.UTC); !c.GetEdited().Equal(want) {
+		t.Fatalf("expect edited %s, got %s", want, c.GetEdited())
+	}
+
+	c = ContactKind{}
+	bs = []byte(`<entry xmlns='http://www.w3.org/2005/Atom'>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+</entry>`)
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if !c.GetEdited().Equal(c.GetUpdated()) || c.GetEdited().IsZero() {
+		t.Fatalf("expect edited to fall back to updated, got %s", c.GetEdited())
+	}
+}
+
+func TestContactKindNamespaces(t *testing.T) {
+	c := ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}}
+
+	b, err := xml.Marshal(c)
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	s := string(b)
+	if !strings.HasPrefix(s, `<entry xmlns:atom="http://www.w3.org/2005/Atom" xmlns:gd="http://schemas.google.com/g/2005">`) {
+		t.Fatalf("xml marshal error: namespace declarations not match, got %s", s)
+	}
+	if strings.Contains(s, "xmlns:gContact") {
+		t.Fatalf("xml marshal error: gContact declared without gContact fields, got %s", s)
+	}
+
+	bb, err := xml.Marshal(c)
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	if string(bb) != s {
+		t.Fatalf("xml marshal error: output not deterministic")
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// insignificantSpace matches the whitespace between elements.
+var insignificantSpace = regexp.MustCompile(`>\s+<`)
+
+// normalizeXML removes the whitespace between elements.
+func normalizeXML(b []byte) string {
+	return strings.TrimSpace(insignificantSpace.ReplaceAllString(string(b), "><"))
+}
+
+// TestContactKindMarshalGolden compares the marshaled contacts with the golden
+// files in testdata/golden. Run with -update to rewrite the golden files.
+func TestContactKindMarshalGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		contact ContactKind
+	}{
+		{
+			name: "full",
+			contact: ContactKind{
+				Name: GDName{GivenName: "Elizabeth", FamilyName: "Bennet", FullName: "Elizabeth Bennet"},
+				Email: []GDEmail{
+					{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#work", Primary: true},
+					{Address: "liz@example.org", Related: "http://schemas.google.com/g/2005#home"},
+				},
+				PhoneNumber: []GDPhoneNumber{
+					{Related: "http://schemas.google.com/g/2005#work", Primary: true, DialNumber: "(206)555-1212"},
+				},
+				StructuredPostalAddress: []GDStructuredPostalAddress{
+					{Related: "http://schemas.google.com/g/2005#work", Street: "1600 Amphitheatre Pkwy", City: "Mountain View", Region: "CA", PostCode: "94043"},
+				},
+				IM: []GDIM{
+					{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#home", Protocol: "http://schemas.google.com/g/2005#GOOGLE_TALK"},
+				},
+				content: "My good friend, Liz.",
+			},
+		},
+		{
+			name:    "name-only",
+			contact: ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}},
+		},
+		{
+			name: "email-only",
+			contact: ContactKind{Email: []GDEmail{
+				{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#home"},
+			}},
+		},
+		{
+			name: "extended-properties",
+			contact: ContactKind{
+				Name:             GDName{FullName: "Elizabeth Bennet"},
+				ExtendedProperty: map[string]string{"source": "import", "external-id": "42"},
+			},
+		},
+		{
+			name: "with-org",
+			contact: ContactKind{
+				Name: GDName{FullName: "Elizabeth Bennet"},
+				Organization: []GDOrganization{
+					{Related: "http://schemas.google.com/g/2005#work", Primary: true, Name: "Longbourn", Title: "Heiress"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join("testdata", "golden", tc.name+".xml")
+			if *update {
+				b, err := xml.MarshalIndent(tc.contact, "", "  ")
+				if err != nil {
+					t.Fatalf("xml marshal error: %v", err)
+				}
+				if err = os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+					t.Fatalf("write golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file: %v", err)
+			}
+			got, err := xml.Marshal(tc.contact)
+			if err != nil {
+				t.Fatalf("xml marshal error: %v", err)
+			}
+			if normalizeXML(got) != normalizeXML(want) {
+				t.Fatalf("xml marshal error: not match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+func TestContactKindPrefixes(t *testing.T) {
+	bs := []byte(`<a:entry xmlns:a='http://www.w3.org/2005/Atom' xmlns:g='http://schemas.google.com/g/2005'>
+  <a:category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <a:id>http://www.google.com/m8/feeds/contacts/example.com/base/abc</a:id>
+  <a:updated>2023-08-18T09:54:17.202Z</a:updated>
+  <a:content>note</a:content>
+  <g:name><g:fullName>Elizabeth Bennet</g:fullName></g:name>
+  <g:email rel='http://schemas.google.com/g/2005#work' address='liz@gmail.com'/>
+  <g:phoneNumber rel='http://schemas.google.com/g/2005#work'>(206)555-1212</g:phoneNumber>
+  <g:structuredPostalAddress rel='http://schemas.google.com/g/2005#work'><g:city>Mountain View</g:city></g:structuredPostalAddress>
+  <g:extendedProperty name='source' value='import'/>
+</a:entry>`)
+
+	var c ContactKind
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if c.GetID() != "abc" || c.GetUpdated().IsZero() || c.content != "note" || c.Name.FullName != "Elizabeth Bennet" ||
+		len(c.Email) != 1 || len(c.PhoneNumber) != 1 || c.PhoneNumber[0].DialNumber != "(206)555-1212" ||
+		len(c.StructuredPostalAddress) != 1 || c.StructuredPostalAddress[0].City != "Mountain View" ||
+		c.ExtendedProperty["source"] != "import" {
+
+		t.Fatalf("xml unmarshal error: fields with other prefixes not populated, got %+v", c)
+	}
+
+	// every prefix in the marshaled entry must be declared on the root element.
+	b, err := xml.Marshal(c)
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	d := xml.NewDecoder(strings.NewReader(string(b)))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("xml marshal error: invalid output: %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Space != "" && !strings.Contains(se.Name.Space, "://") {
+			t.Fatalf("xml marshal error: undeclared prefix %q on %s", se.Name.Space, se.Name.Local)
+		}
+	}
+}
+
+func TestContactKindUserDefinedField(t *testing.T) {
+	c := ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		UserDefinedField: []GContactUserDefinedField{
+			{Key: "sister", Value: "Jane"},
+			{Key: "estate", Value: "Longbourn"},
+		},
+	}
+
+	b, err := xml.Marshal(c)
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	if !strings.Contains(string(b), `xmlns:gContact="http://schemas.google.com/contact/2008"`) {
+		t.Fatalf("xml marshal error: gContact not declared, got %s", b)
+	}
+
+	// the marshaled entry declares no default namespace; it's the Atom namespace.
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.DefaultSpace = nsAtom
+	var o ContactKind
+	if err := d.Decode(&o); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if len(o.UserDefinedField) != 2 || o.UserDefinedField[0] != c.UserDefinedField[0] || o.UserDefinedField[1] != c.UserDefinedField[1] {
+		t.Fatalf("user defined fields not match: expect %v, got %v", c.UserDefinedField, o.UserDefinedField)
+	}
+}
+
+func TestContactKindGroupMembershipInfo(t *testing.T) {
+	c := ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		GroupMembership: []GroupMembershipInfo{
+			{Href: "http://www.google.com/m8/feeds/groups/example.com/base/6"},
+			{Href: "http://www.google.com/m8/feeds/groups/example.com/base/7", Deleted: true},
+		},
+	}
+
+	b, err := xml.Marshal(c)
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	if !strings.Contains(string(b), `<entry xmlns:atom="http://www.w3.org/2005/Atom" xmlns:gd="http://schemas.google.com/g/2005" xmlns:gContact="http://schemas.google.com/contact/2008">`) {
+		t.Fatalf("xml marshal error: gContact not declared on the entry, got %s", b)
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.DefaultSpace = nsAtom
+	var o ContactKind
+	if err := d.Decode(&o); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if len(o.GroupMembership) != 2 || o.GroupMembership[0] != c.GroupMembership[0] || o.GroupMembership[1] != c.GroupMembership[1] {
+		t.Fatalf("group memberships not match: expect %v, got %v", c.GroupMembership, o.GroupMembership)
+	}
+}
+
+func TestContactKindTitle(t *testing.T) {
+	bs := []byte(`<entry xmlns='http://www.w3.org/2005/Atom'>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <title type='html'>&lt;b&gt;Elizabeth&lt;/b&gt; Bennet &amp;amp; co</title>
+</entry>`)
+	var c ContactKind
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if c.GetTitle() != "Elizabeth Bennet & co" {
+		t.Fatalf("expect the text of the html title, got %q", c.GetTitle())
+	}
+	if raw, typ := c.GetRawTitle(); raw != "<b>Elizabeth</b> Bennet &amp; co" || typ != "html" {
+		t.Fatalf("expect the raw html title, got %q of type %q", raw, typ)
+	}
+
+	bs = []byte(`<entry xmlns='http://www.w3.org/2005/Atom'>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <title type='text'>Elizabeth &lt;Liz&gt; Bennet</title>
+</entry>`)
+	c = ContactKind{}
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if c.GetTitle() != "Elizabeth <Liz> Bennet" {
+		t.Fatalf("expect the text title as is, got %q", c.GetTitle())
+	}
+}
+
+func TestContactKindMarshalCounts(t *testing.T) {
+	c := ContactKind{
+		Name:                    GDName{FullName: "Elizabeth Bennet"},
+		Email:                   []GDEmail{{Address: "liz@gmail.com"}, {Address: "liz@example.com"}},
+		PhoneNumber:             []GDPhoneNumber{{DialNumber: "(206)555-1212"}, {DialNumber: "(206)555-1213"}},
+		StructuredPostalAddress: []GDStructuredPostalAddress{{City: "Mountain View"}},
+		IM:                      []GDIM{{Address: "liz@gmail.com"}},
+		ExtendedProperty:        map[string]string{"source": "import", "external-id": "42"},
+	}
+
+	b, err := xml.Marshal(c)
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	for elem, n := range map[string]int{
+		"<gd:email ":                  len(c.Email),
+		"<gd:phoneNumber>":            len(c.PhoneNumber),
+		"<gd:structuredPostalAddress": len(c.StructuredPostalAddress),
+		"<gd:im ":                     len(c.IM),
+		"<gd:extendedProperty ":       len(c.ExtendedProperty),
+	} {
+		if got := strings.Count(string(b), elem); got != n {
+			t.Errorf("expect %d %s elements, got %d", n, elem, got)
+		}
+	}
+}
+
+func TestContactKindWriteAtomFile(t *testing.T) {
+	c := ContactKind{
+		Name:  GDName{FullName: "Elizabeth Bennet"},
+		Email: []GDEmail{{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#home", Primary: true}},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := c.WriteAtomFile(buf); err != nil {
+		t.Fatalf("WriteAtomFile error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") || !strings.Contains(buf.String(), "\n  <gd:email") {
+		t.Fatalf("expect an indented entry after the xml declaration, got %s", buf)
+	}
+
+	// the document is namespaced on its own.
+	var got ContactKind
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if !got.Equal(c) {
+		t.Fatalf("expect %+v, got %+v", c, got)
+	}
+}
+
+func TestContactKindMarshalOmitsDeleted(t *testing.T) {
+	bs := []byte(`<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005'>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  <gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>
+  <gd:deleted/>
+</entry>`)
+	var c ContactKind
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if !c.IsDeleted() {
+		t.Fatalf("expect the contact flagged deleted")
+	}
+
+	for _, v := range []interface{}{c, payload{contact: &c}} {
+		b, err := xml.Marshal(v)
+		if err != nil {
+			t.Fatalf("xml marshal error: %v", err)
+		}
+		if strings.Contains(string(b), "deleted") {
+			t.Fatalf("xml marshal error: expect no gd:deleted, got %s", b)
+		}
+	}
+}
+
+func TestContactKindBirthday(t *testing.T) {
+	for _, tc := range []struct {
+		when string
+		want GContactBirthday
+	}{
+		{"1985-06-01", GContactBirthday{Year: 1985, Month: time.June, Day: 1}},
+		{"--06-01", GContactBirthday{Month: time.June, Day: 1}},
+		{"--02-29", GContactBirthday{Month: time.February, Day: 29}},
+	} {
+		bs := []byte(testEntry("abc", `"v1"`, `<gContact:birthday xmlns:gContact='http://schemas.google.com/contact/2008' when='`+tc.when+`'/>`))
+		var c ContactKind
+		if err := xml.Unmarshal(bs, &c); err != nil {
+			t.Fatalf("xml unmarshal error: %v", err)
+		}
+		if c.Birthday != tc.want {
+			t.Fatalf("expect birthday %+v for %s, got %+v", tc.want, tc.when, c.Birthday)
+		}
+
+		b, err := xml.Marshal(c)
+		if err != nil {
+			t.Fatalf("xml marshal error: %v", err)
+		}
+		if !strings.Contains(string(b), `<gContact:birthday when="`+tc.when+`"></gContact:birthday>`) {
+			t.Fatalf("xml marshal error: expect the birthday %s, got %s", tc.when, b)
+		}
+		d := xml.NewDecoder(bytes.NewReader(b))
+		d.DefaultSpace = nsAtom
+		var o ContactKind
+		if err := d.Decode(&o); err != nil {
+			t.Fatalf("xml unmarshal error: %v", err)
+		}
+		if o.Birthday != tc.want {
+			t.Fatalf("expect birthday %+v after a round trip, got %+v", tc.want, o.Birthday)
+		}
+	}
+
+	b, err := xml.Marshal(ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}})
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	if strings.Contains(string(b), "birthday") || strings.Contains(string(b), "gContact") {
+		t.Fatalf("xml marshal error: expect no birthday, got %s", b)
+	}
+
+	if _, err := ParseBirthday("06/01/1985"); err == nil {
+		t.Fatalf("expect an error for an invalid birthday")
+	}
+}
+
+func TestListContactsInvalidBirthday(t *testing.T) {
+	const bday = `<gContact:birthday xmlns:gContact='http://schemas.google.com/contact/2008' when='06/01/1985'/>`
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, bday), testEntry("def", `"v1"`, ""))
+	}))
+
+	cs, _, err := s.ListContacts(context.Background(), "full", "")
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if len(cs) != 2 {
+		t.Fatalf("expect both contacts, got %d", len(cs))
+	}
+	if c := cs[0]; c.Birthday.Year != 0 || c.Birthday.Month != 0 || c.Birthday.Day != 0 {
+		t.Fatalf("expect no birthday date, got %+v", c.Birthday)
+	}
+	if err = cs[0].Validate(); err == nil || !strings.Contains(err.Error(), "06/01/1985") {
+		t.Fatalf("expect Validate to report the birthday, got %v", err)
+	}
+
+	// the value is sent back as it was received.
+	b, err := xml.Marshal(cs[0])
+	if err != nil {
+		t.Fatalf("xml marshal error: %v", err)
+	}
+	if !strings.Contains(string(b), `<gContact:birthday when="06/01/1985"></gContact:birthday>`) {
+		t.Fatalf("expect the birthday kept, got %s", b)
+	}
+}
+
+func TestContactKindDraft(t *testing.T) {
+	for _, tc := range []struct {
+		control string
+		want    bool
+	}{
+		{`<app:control><app:draft>yes</app:draft></app:control>`, true},
+		{`<app:control><app:draft>no</app:draft></app:control>`, false},
+		{``, false},
+	} {
+		bs := []byte(`<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' xmlns:app='http://www.w3.org/2007/app'>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+  ` + tc.control + `
+  <gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>
+</entry>`)
+		var c ContactKind
+		if err := xml.Unmarshal(bs, &c); err != nil {
+			t.Fatalf("xml unmarshal error: %v", err)
+		}
+		if c.IsDraft() != tc.want {
+			t.Fatalf("expect IsDraft %v for %q", tc.want, tc.control)
+		}
+	}
+}
+
+func TestContactKindChangeSummary(t *testing.T) {
+	bs := []byte(`<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' xmlns:app='http://www.w3.org/2007/app' gd:etag='"v1"'>
+  <id>http://www.google.com/m8/feeds/contacts/example.com/base/abc</id>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+  <app:edited>2023-08-18T09:54:18.000Z</app:edited>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#contact'/>
+</entry>`)
+	var c ContactKind
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+
+	sum := c.ChangeSummary()
+	if sum.ID != "abc" || sum.Etag != `"v1"` ||
+		!sum.Updated.Equal(time.Date(2023, 8, 18, 9, 54, 17, 202e6, time.UTC)) ||
+		!sum.Edited.Equal(time.Date(2023, 8, 18, 9, 54, 18, 0, time.UTC)) {
+		t.Fatalf("change summary not match: %+v", sum)
+	}
+}
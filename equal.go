@@ -0,0 +1,105 @@
+package contacts
+
+import "strings"
+
+// EqualOptions relaxes the comparison of EqualFunc.
+type EqualOptions struct {
+	// IgnorePrimary ignores the primary flags.
+	IgnorePrimary bool
+	// IgnoreLabels ignores the labels.
+	IgnoreLabels bool
+	// CaseInsensitiveRel compares the rel values case-insensitively.
+	CaseInsensitiveRel bool
+	// IgnoreOrder compares the repeated elements regardless of their order.
+	IgnoreOrder bool
+}
+
+// Equal reports whether c and other have the same data. The server
+// metadata, such as the id, the etag and the links, isn't compared.
+func (c ContactKind) Equal(other ContactKind) bool {
+	return c.EqualFunc(other, EqualOptions{})
+}
+
+// EqualFunc reports whether c and other have the same data, compared as
+// relaxed by opts. Like Equal, it doesn't compare the server metadata.
+func (c ContactKind) EqualFunc(other ContactKind, opts EqualOptions) bool {
+	if c.Name != other.Name || c.content != other.content || c.Birthday != other.Birthday {
+		return false
+	}
+	if len(c.ExtendedProperty) != len(other.ExtendedProperty) {
+		return false
+	}
+	for k, v := range c.ExtendedProperty {
+		if w, ok := other.ExtendedProperty[k]; !ok || v != w {
+			return false
+		}
+	}
+
+	return equalEntries(c.Email, other.Email, opts.IgnoreOrder, func(m GDEmail) GDEmail {
+		// the demotion is how the email is sent, not its data.
+		m.demoted = false
+		opts.normalize(&m.Related, &m.Label, &m.Primary)
+		return m
+	}) && equalEntries(c.PhoneNumber, other.PhoneNumber, opts.IgnoreOrder, func(n GDPhoneNumber) GDPhoneNumber {
+		opts.normalize(&n.Related, &n.Label, &n.Primary)
+		return n
+	}) && equalEntries(c.IM, other.IM, opts.IgnoreOrder, func(im GDIM) GDIM {
+		opts.normalize(&im.Related, &im.Label, &im.Primary)
+		return im
+	}) && equalEntries(c.StructuredPostalAddress, other.StructuredPostalAddress, opts.IgnoreOrder, func(a GDStructuredPostalAddress) GDStructuredPostalAddress {
+		opts.normalize(&a.Related, &a.Label, &a.Primary)
+		return a
+	}) && equalEntries(c.Organization, other.Organization, opts.IgnoreOrder, func(org GDOrganization) GDOrganization {
+		opts.normalize(&org.Related, &org.Label, &org.Primary)
+		return org
+	}) && equalEntries(c.UserDefinedField, other.UserDefinedField, opts.IgnoreOrder, nil) &&
+		equalEntries(c.GroupMembership, other.GroupMembership, opts.IgnoreOrder, nil)
+}
+
+// normalize clears or folds the rel, label and primary flag of an entry
+// that the options ignore.
+func (opts EqualOptions) normalize(rel, label *string, primary *bool) {
+	if opts.IgnorePrimary {
+		*primary = false
+	}
+	if opts.IgnoreLabels {
+		*label = ""
+	}
+	if opts.CaseInsensitiveRel {
+		*rel = strings.ToLower(*rel)
+	}
+}
+
+// equalEntries reports whether a and b have the same entries after norm,
+// in the same order unless ignoreOrder is set. A nil norm compares the
+// entries as they are.
+func equalEntries[T comparable](a, b []T, ignoreOrder bool, norm func(T) T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if norm == nil {
+		norm = func(v T) T { return v }
+	}
+
+	if !ignoreOrder {
+		for i := range a {
+			if norm(a[i]) != norm(b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	counts := make(map[T]int, len(a))
+	for _, v := range a {
+		counts[norm(v)]++
+	}
+	for _, v := range b {
+		k := norm(v)
+		if counts[k] == 0 {
+			return false
+		}
+		counts[k]--
+	}
+	return true
+}
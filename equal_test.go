@@ -0,0 +1,47 @@
+package contacts
+
+import "testing"
+
+func TestContactKindEqualFunc(t *testing.T) {
+	a := ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		Email: []GDEmail{
+			{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#work", Primary: true},
+			{Address: "liz@example.org", Label: "Longbourn"},
+		},
+	}
+	if !a.Equal(a.Clone()) {
+		t.Fatalf("expect a contact equal to its clone")
+	}
+	b := a.CloneData()
+	b.id, b.etag = "http://www.google.com/m8/feeds/contacts/example.com/base/abc", `"v1"`
+	if !a.Equal(b) {
+		t.Fatalf("expect the metadata not compared")
+	}
+
+	for _, tc := range []struct {
+		name   string
+		change func(*ContactKind)
+		opts   EqualOptions
+	}{
+		{"primary", func(c *ContactKind) { c.Email[0].Primary = false }, EqualOptions{IgnorePrimary: true}},
+		{"label", func(c *ContactKind) { c.Email[1].Label = "Netherfield" }, EqualOptions{IgnoreLabels: true}},
+		{"rel", func(c *ContactKind) { c.Email[0].Related = "http://schemas.google.com/g/2005#WORK" }, EqualOptions{CaseInsensitiveRel: true}},
+		{"order", func(c *ContactKind) { c.Email[0], c.Email[1] = c.Email[1], c.Email[0] }, EqualOptions{IgnoreOrder: true}},
+	} {
+		b := a.Clone()
+		tc.change(&b)
+		if a.Equal(b) {
+			t.Errorf("%s: expect the contacts to differ", tc.name)
+		}
+		if !a.EqualFunc(b, tc.opts) {
+			t.Errorf("%s: expect the contacts equal with %+v", tc.name, tc.opts)
+		}
+	}
+
+	c := a.Clone()
+	c.Email[1].Address = "lizzy@example.org"
+	if a.EqualFunc(c, EqualOptions{IgnorePrimary: true, IgnoreLabels: true, CaseInsensitiveRel: true, IgnoreOrder: true}) {
+		t.Fatalf("expect different addresses to differ with every option")
+	}
+}
@@ -0,0 +1,77 @@
+package contacts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrConflict is returned when the etag of a conditional request doesn't
+// match the current version at server side (HTTP 412 PRECONDITION FAILED),
+// or when the server reports a version conflict (HTTP 409 CONFLICT).
+var ErrConflict = errors.New("contacts: version conflict")
+
+// ErrNotFound is returned when the requested resource doesn't exist (HTTP 404 NOT FOUND).
+var ErrNotFound = errors.New("contacts: not found")
+
+// ErrNotModified is returned when a conditional retrieve finds the resource
+// unchanged since the given etag (HTTP 304 NOT MODIFIED).
+var ErrNotModified = errors.New("contacts: not modified")
+
+// PageError reports the failure of a page of a feed after the first one.
+// With WithPartialResults, ListContacts returns it with the contacts of
+// the pages before Page.
+type PageError struct {
+	// Page is the 1-based number of the page that failed.
+	Page int
+	Err  error
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("contacts: page %d: %v", e.Page, e.Err)
+}
+
+func (e *PageError) Unwrap() error { return e.Err }
+
+// maxErrorBody limits how much of an error response is kept in APIError.
+const maxErrorBody = 64 << 10
+
+// APIError reports a response with an unexpected HTTP status, along with
+// the error text the server sent. errors.Is matches it against ErrNotFound
+// for HTTP 404, and against ErrConflict for HTTP 409 and 412.
+type APIError struct {
+	StatusCode int    // e.g. 503
+	Status     string // e.g. "503 Service Unavailable"
+	// Body is the error text of the response, with surrounding spaces trimmed.
+	Body string
+}
+
+// newAPIError reads the error text of res. The caller still closes the body.
+func newAPIError(res *http.Response) *APIError {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	return &APIError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       strings.TrimSpace(string(b)),
+	}
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return "contacts: " + e.Status
+	}
+	return fmt.Sprintf("contacts: %s: %s", e.Status, e.Body)
+}
+
+// Is reports whether target is the sentinel error of the status code.
+func (e *APIError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return target == ErrConflict
+	}
+	return false
+}
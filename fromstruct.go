@@ -0,0 +1,126 @@
+package contacts
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ContactFromStruct builds a contact from the tagged fields of v, a struct
+// or a pointer to a struct. The tag of a field is
+//
+//	contacts:"element[,option]..."
+//
+// where element is one of
+//
+//	fullName, givenName, additionalName, familyName, namePrefix, nameSuffix
+//	email, phoneNumber, im, structuredPostalAddress
+//	extendedProperty
+//
+// The name elements fill Name. email, phoneNumber and im add an entry with
+// the field value as the address or number; structuredPostalAddress adds
+// an address with the field value as the formatted address. Their options
+// are a rel name, such as work, home, other or mobile, which is expanded to
+// the rel value, e.g. http://schemas.google.com/g/2005#work, and primary.
+// extendedProperty takes the property name as its option, defaulting to
+// the field name.
+//
+// The tagged fields must be strings or string slices; a slice adds an entry
+// for each of its elements. Empty values are skipped, as are fields
+// without a tag or tagged "-".
+func ContactFromStruct(v interface{}) (*ContactKind, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ContactFromStruct error: expect a struct, got %T", v)
+	}
+
+	c := &ContactKind{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, ok := f.Tag.Lookup("contacts")
+		if !ok || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		var values []string
+		switch fv := rv.Field(i); {
+		case fv.Kind() == reflect.String:
+			values = []string{fv.String()}
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < fv.Len(); j++ {
+				values = append(values, fv.Index(j).String())
+			}
+		default:
+			return nil, fmt.Errorf("ContactFromStruct error: field %s: expect a string or a string slice, got %s", f.Name, f.Type)
+		}
+
+		parts := strings.Split(tag, ",")
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			if err := c.setField(parts[0], parts[1:], f.Name, value); err != nil {
+				return nil, fmt.Errorf("ContactFromStruct error: field %s: %w", f.Name, err)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// setField sets value to the element of a contacts struct tag.
+func (c *ContactKind) setField(element string, opts []string, field, value string) error {
+	var rel string
+	var primary bool
+	if element != "extendedProperty" {
+		for _, opt := range opts {
+			switch {
+			case opt == "primary":
+				primary = true
+			case opt != "" && rel == "":
+				rel = relBase + opt
+			default:
+				return fmt.Errorf("invalid option %q of %s", opt, element)
+			}
+		}
+	}
+
+	switch element {
+	case "fullName":
+		c.Name.FullName = value
+	case "givenName":
+		c.Name.GivenName = value
+	case "additionalName":
+		c.Name.AdditionalName = value
+	case "familyName":
+		c.Name.FamilyName = value
+	case "namePrefix":
+		c.Name.Prefix = value
+	case "nameSuffix":
+		c.Name.Suffix = value
+	case "email":
+		c.Email = append(c.Email, GDEmail{Address: value, Related: rel, Primary: primary})
+	case "phoneNumber":
+		c.PhoneNumber = append(c.PhoneNumber, GDPhoneNumber{DialNumber: value, Related: rel, Primary: primary})
+	case "im":
+		c.IM = append(c.IM, GDIM{Address: value, Related: rel, Primary: primary})
+	case "structuredPostalAddress":
+		c.StructuredPostalAddress = append(c.StructuredPostalAddress, GDStructuredPostalAddress{FormattedAddress: value, Related: rel, Primary: primary})
+	case "extendedProperty":
+		name := field
+		if len(opts) > 0 && opts[0] != "" {
+			name = opts[0]
+		}
+		if c.ExtendedProperty == nil {
+			c.ExtendedProperty = make(map[string]string)
+		}
+		c.ExtendedProperty[name] = value
+	default:
+		return fmt.Errorf("unknown element %q", element)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package contacts
+
+import "testing"
+
+func TestContactFromStruct(t *testing.T) {
+	type employee struct {
+		Given    string   `contacts:"givenName"`
+		Family   string   `contacts:"familyName"`
+		Email    string   `contacts:"email,work,primary"`
+		Aliases  []string `contacts:"email,other"`
+		Mobile   string   `contacts:"phoneNumber,mobile"`
+		Badge    string   `contacts:"extendedProperty,badge"`
+		Team     string   `contacts:"-"`
+		Untagged string
+	}
+
+	c, err := ContactFromStruct(&employee{
+		Given:    "Elizabeth",
+		Family:   "Bennet",
+		Email:    "liz@example.com",
+		Aliases:  []string{"lizzy@example.com", ""},
+		Mobile:   "(206)555-1212",
+		Badge:    "42",
+		Team:     "Longbourn",
+		Untagged: "ignored",
+	})
+	if err != nil {
+		t.Fatalf("ContactFromStruct error: %v", err)
+	}
+
+	if c.Name.GivenName != "Elizabeth" || c.Name.FamilyName != "Bennet" {
+		t.Fatalf("name not match: %+v", c.Name)
+	}
+	if len(c.Email) != 2 ||
+		c.Email[0] != (GDEmail{Address: "liz@example.com", Related: relBase + "work", Primary: true}) ||
+		c.Email[1] != (GDEmail{Address: "lizzy@example.com", Related: relOther}) {
+		t.Fatalf("emails not match: %+v", c.Email)
+	}
+	if len(c.PhoneNumber) != 1 || c.PhoneNumber[0].DialNumber != "(206)555-1212" || c.PhoneNumber[0].Related != relBase+"mobile" {
+		t.Fatalf("phone numbers not match: %+v", c.PhoneNumber)
+	}
+	if len(c.ExtendedProperty) != 1 || c.ExtendedProperty["badge"] != "42" {
+		t.Fatalf("extended properties not match: %v", c.ExtendedProperty)
+	}
+
+	if _, err = ContactFromStruct(struct {
+		Age int `contacts:"fullName"`
+	}{Age: 1}); err == nil {
+		t.Fatalf("expect error for a non-string field")
+	}
+	if _, err = ContactFromStruct(struct {
+		Nick string `contacts:"nickname"`
+	}{Nick: "Lizzy"}); err == nil {
+		t.Fatalf("expect error for an unknown element")
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/markxp/contacts
 
-go 1.20
+go 1.23
 
 require (
 	golang.org/x/oauth2 v0.12.0
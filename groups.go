@@ -0,0 +1,314 @@
+package contacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var groupsBaseURL = "https://www.google.com/m8/feeds/groups/%s/full"
+
+// GroupKind is a contact group of the domain.
+type GroupKind struct {
+	Title            string
+	ExtendedProperty map[string]string
+
+	systemGroup string
+	editLink    string
+	id          string
+	updated     time.Time
+	etag        string
+}
+
+// GetID returns the ID of the group entry.
+// It's the short form, the last path segment of the full ID.
+func (g GroupKind) GetID() string {
+	idx := strings.LastIndex(g.id, "/")
+	return g.id[idx+1:]
+}
+
+// GetFullID returns the complete ID URL of the group entry. It's the href
+// of the contacts' group memberships.
+func (g GroupKind) GetFullID() string { return g.id }
+
+// GetSystemGroup returns the id of the system group, e.g. "Contacts",
+// or empty for user-created groups.
+func (g GroupKind) GetSystemGroup() string { return g.systemGroup }
+
+// GetEditLink returns the edit link of the group entry.
+func (g GroupKind) GetEditLink() string { return g.editLink }
+
+// GetUpdated returns the last updated time of the group entry.
+func (g GroupKind) GetUpdated() time.Time { return g.updated }
+
+// GetEtag returns the etag of the group entry.
+func (g GroupKind) GetEtag() string { return g.etag }
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (g *GroupKind) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type decodeGroupKind struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom entry"`
+		Etag     string   `xml:"etag,attr"`
+		Category struct {
+			Term string `xml:"term,attr"`
+		} `xml:"http://www.w3.org/2005/Atom category"`
+		ID          string    `xml:"http://www.w3.org/2005/Atom id"`
+		Updated     time.Time `xml:"http://www.w3.org/2005/Atom updated"`
+		Title       string    `xml:"http://www.w3.org/2005/Atom title"`
+		SystemGroup struct {
+			ID string `xml:"id,attr"`
+		} `xml:"http://schemas.google.com/contact/2008 systemGroup"`
+		Link             []Link               `xml:"http://www.w3.org/2005/Atom link"`
+		ExtendedProperty []GDExtendedProperty `xml:"http://schemas.google.com/g/2005 extendedProperty"`
+	}
+
+	var o decodeGroupKind
+	if err := d.DecodeElement(&o, &start); err != nil {
+		return err
+	}
+	const groupTerm = "http://schemas.google.com/contact/2008#group"
+	if o.Category.Term != groupTerm {
+		return fmt.Errorf("xml type not match: expect %s, got %s", groupTerm, o.Category.Term)
+	}
+
+	g.Title = o.Title
+	g.ExtendedProperty = make(map[string]string, len(o.ExtendedProperty))
+	for _, pair := range o.ExtendedProperty {
+		g.ExtendedProperty[pair.Name] = pair.Value
+	}
+	for _, l := range o.Link {
+		if l.Related == "edit" {
+			g.editLink = l.Href
+		}
+	}
+	g.systemGroup = o.SystemGroup.ID
+	g.id = o.ID
+	g.updated = o.Updated
+	g.etag = o.Etag
+	return nil
+}
+
+// MarshalXML implements xml.Marshaler.
+// Like ContactKind, only the fields a client sets are encoded.
+func (g GroupKind) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type encodeGroupKind struct {
+		XmlnsAtom string `xml:"xmlns:atom,attr"`
+		XmlnsGD   string `xml:"xmlns:gd,attr"`
+		Category  struct {
+			Scheme string `xml:"scheme,attr"`
+			Term   string `xml:"term,attr"`
+		} `xml:"category"`
+		Title            string               `xml:"title"`
+		ExtendedProperty []GDExtendedProperty `xml:"gd:extendedProperty,omitempty"`
+	}
+
+	o := encodeGroupKind{
+		XmlnsAtom:        nsAtom,
+		XmlnsGD:          nsGD,
+		Title:            g.Title,
+		ExtendedProperty: extendedProperties(g.ExtendedProperty),
+	}
+	o.Category.Scheme = "http://schemas.google.com/g/2005#kind"
+	o.Category.Term = "http://schemas.google.com/contact/2008#group"
+
+	start.Name = xml.Name{Space: "", Local: "entry"}
+	return e.EncodeElement(o, start)
+}
+
+// CreateGroup creates a group. Its return value is the saved version at server side.
+func (s *service) CreateGroup(ctx context.Context, g *GroupKind) (*GroupKind, error) {
+	return s.sendGroup(ctx, "CreateGroup", http.MethodPost, fmt.Sprintf(groupsBaseURL, s.domain), "", g)
+}
+
+// GetGroup retrieves a group. If etag is provided, it uses conditional
+// retrieves (returns nil, ErrNotModified for HTTP 304 NOT MODIFIED).
+func (s *service) GetGroup(ctx context.Context, id, etag string) (*GroupKind, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(groupsBaseURL, s.domain)+"/"+id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GetGroup error: could not create a HTTP request: %w", err)
+	}
+	if etag != "" && etag != "*" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	res, err := s.base.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GetGroup error: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, fmt.Errorf("GetGroup error: %w", ErrNotModified)
+	default:
+		return nil, fmt.Errorf("GetGroup error: %w", newAPIError(res))
+	}
+
+	var g GroupKind
+	if err = xml.NewDecoder(res.Body).Decode(&g); err != nil {
+		return nil, fmt.Errorf("GetGroup error: %w", err)
+	}
+	return &g, nil
+}
+
+// ListGroups retrieves the groups of the domain, following the next links of the feed.
+func (s *service) ListGroups(ctx context.Context, queries ...func(url.Values)) ([]*GroupKind, error) {
+	u := fmt.Sprintf(groupsBaseURL, s.domain)
+	if len(queries) > 0 {
+		params := url.Values{}
+		if err := applyQueries(params, queries); err != nil {
+			return nil, fmt.Errorf("ListGroups error: %w", err)
+		}
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ListGroups error: could not create a HTTP request: %w", err)
+	}
+
+	type feed struct {
+		Links  []Link      `xml:"link"`
+		Groups []GroupKind `xml:"http://www.w3.org/2005/Atom entry"`
+	}
+
+	ret := make([]*GroupKind, 0, 20)
+	for req != nil {
+		res, err := s.base.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("ListGroups error: %w", err)
+		}
+		if res.StatusCode != http.StatusOK {
+			err = newAPIError(res)
+			res.Body.Close()
+			return nil, fmt.Errorf("ListGroups error: %w", err)
+		}
+
+		f := new(feed)
+		err = xml.NewDecoder(res.Body).Decode(f)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ListGroups error: %w", err)
+		}
+		for i := range f.Groups {
+			ret = append(ret, &f.Groups[i])
+		}
+
+		req = nil
+		for _, l := range f.Links {
+			if l.Related == "next" {
+				req, err = http.NewRequestWithContext(ctx, http.MethodGet, l.Href, nil)
+				if err != nil {
+					return nil, fmt.Errorf("ListGroups error: could not create a HTTP request: %w", err)
+				}
+				break
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// UpdateGroup changes a group. If etag is provided, only the version is met
+// will be updated, otherwise it returns ErrConflict, as for an empty etag.
+// If etag equals to '*', it overwrites the current version.
+func (s *service) UpdateGroup(ctx context.Context, id, etag string, g *GroupKind) (*GroupKind, error) {
+	if etag == "" {
+		return nil, fmt.Errorf("UpdateGroup error: %w", ErrConflict)
+	}
+	return s.sendGroup(ctx, "UpdateGroup", http.MethodPut, fmt.Sprintf(groupsBaseURL, s.domain)+"/"+id, etag, g)
+}
+
+// DeleteGroup deletes a group. If etag is provided, only the version is met
+// will be deleted, otherwise it returns ErrConflict, as for an empty etag.
+// If etag equals to '*', it deletes the current version.
+func (s *service) DeleteGroup(ctx context.Context, id, etag string) error {
+	if etag == "" {
+		return fmt.Errorf("DeleteGroup error: %w", ErrConflict)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf(groupsBaseURL, s.domain)+"/"+id, nil)
+	if err != nil {
+		return fmt.Errorf("DeleteGroup error: could not create a HTTP request: %w", err)
+	}
+	req.Header.Set("If-Match", etag)
+
+	res, err := s.base.Do(req)
+	if err != nil {
+		return fmt.Errorf("DeleteGroup error: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("DeleteGroup error: %w", newAPIError(res))
+	}
+}
+
+// sendGroup sends g with method to u and decodes the saved version.
+// A non-empty etag makes the request conditional.
+func (s *service) sendGroup(ctx context.Context, method, httpMethod, u, etag string, g *GroupKind) (*GroupKind, error) {
+	buf := &bytes.Buffer{}
+	enc := xml.NewEncoder(buf)
+	if err := enc.Encode(g); err != nil {
+		return nil, fmt.Errorf("%s error: could not encode xml payload: %w", method, err)
+	}
+	enc.Close()
+
+	req, err := http.NewRequestWithContext(ctx, httpMethod, u, buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s error: could not create a HTTP request: %w", method, err)
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	res, err := s.base.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	default:
+		return nil, fmt.Errorf("%s error: %w", method, newAPIError(res))
+	}
+
+	var ret GroupKind
+	if err = xml.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	return &ret, nil
+}
+
+// ResolveGroups retrieves the groups the contact is a member of, in the
+// order of its group memberships. Deleted memberships are skipped, and a
+// group listed more than once is fetched once.
+func (s *service) ResolveGroups(ctx context.Context, c *ContactKind) ([]GroupKind, error) {
+	resolved := make(map[string]GroupKind, len(c.GroupMembership))
+	ret := make([]GroupKind, 0, len(c.GroupMembership))
+	for _, m := range c.GroupMembership {
+		if m.Deleted {
+			continue
+		}
+		g, ok := resolved[m.Href]
+		if !ok {
+			got, err := s.GetGroup(ctx, m.Href[strings.LastIndex(m.Href, "/")+1:], "")
+			if err != nil {
+				return nil, fmt.Errorf("ResolveGroups error: %s: %w", m.Href, err)
+			}
+			g = *got
+			resolved[m.Href] = g
+		}
+		ret = append(ret, g)
+	}
+	return ret, nil
+}
@@ -0,0 +1,153 @@
+package contacts
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveGroups(t *testing.T) {
+	const entry = `<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"group-%s"'>
+  <id>http://www.google.com/m8/feeds/groups/example.com/base/%s</id>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#group'/>
+  <title>%s</title>
+</entry>`
+	titles := map[string]string{"6": "Friends", "7": "Family"}
+
+	fetches := map[string]int{}
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/m8/feeds/groups/example.com/full/")
+		title, ok := titles[id]
+		if !ok {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		fetches[id]++
+		fmt.Fprintf(w, entry, id, id, title)
+	}))
+
+	c := &ContactKind{GroupMembership: []GroupMembershipInfo{
+		{Href: "http://www.google.com/m8/feeds/groups/example.com/base/7"},
+		{Href: "http://www.google.com/m8/feeds/groups/example.com/base/6"},
+		{Href: "http://www.google.com/m8/feeds/groups/example.com/base/8", Deleted: true},
+		{Href: "http://www.google.com/m8/feeds/groups/example.com/base/7"},
+	}}
+	groups, err := s.ResolveGroups(context.Background(), c)
+	if err != nil {
+		t.Fatalf("ResolveGroups error: %v", err)
+	}
+	if len(groups) != 3 || groups[0].Title != "Family" || groups[1].Title != "Friends" || groups[2].GetID() != "7" {
+		t.Fatalf("groups not match: %v", groups)
+	}
+	if fetches["6"] != 1 || fetches["7"] != 1 {
+		t.Fatalf("expect each group fetched once, got %v", fetches)
+	}
+}
+
+func TestListGroups(t *testing.T) {
+	feed, err := os.ReadFile(filepath.Join("testdata", "groups-feed.xml"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/m8/feeds/groups/example.com/full" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write(feed)
+	}))
+
+	groups, err := s.ListGroups(context.Background())
+	if err != nil {
+		t.Fatalf("ListGroups error: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expect 2 groups, got %d", len(groups))
+	}
+	if g := groups[0]; g.GetID() != "6" || g.GetSystemGroup() != "Contacts" || g.Title != "System Group: My Contacts" || g.GetEtag() != `"YDwreyM."` {
+		t.Fatalf("system group not match: %s %s %s %s", g.GetID(), g.GetSystemGroup(), g.Title, g.GetEtag())
+	}
+	g := groups[1]
+	if g.GetID() != "4d8aa3a20c7e7b1b" || g.GetSystemGroup() != "" || g.Title != "Netherfield" || g.GetUpdated().IsZero() {
+		t.Fatalf("group not match: %s %s %s %v", g.GetID(), g.GetSystemGroup(), g.Title, g.GetUpdated())
+	}
+	if g.GetEditLink() == "" || g.ExtendedProperty["source"] != "import" {
+		t.Fatalf("group not match: %s %v", g.GetEditLink(), g.ExtendedProperty)
+	}
+}
+
+func TestCreateGroup(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/m8/feeds/groups/example.com/full" {
+			t.Errorf("expect a POST to the groups feed, got %s %s", r.Method, r.URL.Path)
+		}
+		// send back the posted group as saved.
+		d := xml.NewDecoder(r.Body)
+		d.DefaultSpace = nsAtom
+		var g GroupKind
+		if err := d.Decode(&g); err != nil {
+			t.Errorf("decode the posted group: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"v1"'>
+  <id>http://www.google.com/m8/feeds/groups/example.com/base/new</id>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#group'/>
+  <title>%s</title>
+  <gd:extendedProperty name='source' value='%s'/>
+</entry>`, g.Title, g.ExtendedProperty["source"])
+	}))
+
+	g, err := s.CreateGroup(context.Background(), &GroupKind{Title: "Netherfield", ExtendedProperty: map[string]string{"source": "import"}})
+	if err != nil {
+		t.Fatalf("CreateGroup error: %v", err)
+	}
+	if g.GetID() != "new" || g.GetEtag() != `"v1"` || g.Title != "Netherfield" || g.ExtendedProperty["source"] != "import" {
+		t.Fatalf("group not match: %s %s %s %v", g.GetID(), g.GetEtag(), g.Title, g.ExtendedProperty)
+	}
+}
+
+func TestUpdateDeleteGroupConflict(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") != "*" && r.Header.Get("If-Match") != `"v1"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			return
+		}
+		fmt.Fprint(w, `<entry xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"v2"'>
+  <id>http://www.google.com/m8/feeds/groups/example.com/base/abc</id>
+  <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#group'/>
+  <title>Longbourn</title>
+</entry>`)
+	}))
+
+	g, err := s.UpdateGroup(context.Background(), "abc", `"v1"`, &GroupKind{Title: "Longbourn"})
+	if err != nil {
+		t.Fatalf("UpdateGroup error: %v", err)
+	}
+	if g.GetEtag() != `"v2"` {
+		t.Fatalf("expect updated etag, got %q", g.GetEtag())
+	}
+	if _, err = s.UpdateGroup(context.Background(), "abc", `"v0"`, &GroupKind{Title: "Longbourn"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for a stale etag, got %v", err)
+	}
+	if _, err = s.UpdateGroup(context.Background(), "abc", "", &GroupKind{Title: "Longbourn"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for an empty etag, got %v", err)
+	}
+	if err = s.DeleteGroup(context.Background(), "abc", "*"); err != nil {
+		t.Fatalf("DeleteGroup error: %v", err)
+	}
+	if err = s.DeleteGroup(context.Background(), "abc", ""); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for an empty etag, got %v", err)
+	}
+	if err = s.DeleteGroup(context.Background(), "abc", `"v0"`); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for a stale etag, got %v", err)
+	}
+}
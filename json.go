@@ -0,0 +1,123 @@
+package contacts
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// contactJSON is the JSON form of ContactKind, with the server metadata
+// next to the contact data.
+type contactJSON struct {
+	ID        string     `json:"id,omitempty"`
+	Etag      string     `json:"etag,omitempty"`
+	Updated   *time.Time `json:"updated,omitempty"`
+	Edited    *time.Time `json:"edited,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	Draft     bool       `json:"draft,omitempty"`
+	Title     string     `json:"title,omitempty"`
+	TitleType string     `json:"titleType,omitempty"`
+	Content   string     `json:"content,omitempty"`
+
+	Name               GDName                      `json:"name"`
+	Emails             []GDEmail                   `json:"emails,omitempty"`
+	PhoneNumbers       []GDPhoneNumber             `json:"phoneNumbers,omitempty"`
+	Addresses          []GDStructuredPostalAddress `json:"addresses,omitempty"`
+	IMs                []GDIM                      `json:"ims,omitempty"`
+	Organizations      []GDOrganization            `json:"organizations,omitempty"`
+	ExtendedProperties map[string]string           `json:"extendedProperties,omitempty"`
+	UserDefinedFields  []GContactUserDefinedField  `json:"userDefinedFields,omitempty"`
+	GroupMemberships   []GroupMembershipInfo       `json:"groupMemberships,omitempty"`
+	Birthday           *GContactBirthday           `json:"birthday,omitempty"`
+
+	Links *contactLinksJSON `json:"links,omitempty"`
+}
+
+type contactLinksJSON struct {
+	Self  string `json:"self,omitempty"`
+	Edit  string `json:"edit,omitempty"`
+	Photo string `json:"photo,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// Unlike the default encoding, the JSON has the server metadata too: the
+// id (the full ID URL), the etag, the updated and edited times, and the
+// self, edit and photo links, so that a contact cached as JSON can be sent
+// as an update.
+func (c ContactKind) MarshalJSON() ([]byte, error) {
+	o := contactJSON{
+		ID:                 c.id,
+		Etag:               c.etag,
+		Updated:            jsonTime(c.updated),
+		Edited:             jsonTime(c.edited),
+		Deleted:            c.deleted,
+		Draft:              c.draft,
+		Title:              c.title,
+		TitleType:          c.titleType,
+		Content:            c.content,
+		Name:               c.Name,
+		Emails:             c.Email,
+		PhoneNumbers:       c.PhoneNumber,
+		Addresses:          c.StructuredPostalAddress,
+		IMs:                c.IM,
+		Organizations:      c.Organization,
+		ExtendedProperties: c.ExtendedProperty,
+		UserDefinedFields:  c.UserDefinedField,
+		GroupMemberships:   c.GroupMembership,
+	}
+	if !c.Birthday.IsZero() {
+		o.Birthday = &c.Birthday
+	}
+	if c.selfLink != "" || c.editLink != "" || c.photoLink != "" {
+		o.Links = &contactLinksJSON{Self: c.selfLink, Edit: c.editLink, Photo: c.photoLink}
+	}
+	return json.Marshal(o)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It reads the JSON of MarshalJSON.
+func (c *ContactKind) UnmarshalJSON(b []byte) error {
+	var o contactJSON
+	if err := json.Unmarshal(b, &o); err != nil {
+		return err
+	}
+
+	*c = ContactKind{
+		Name:                    o.Name,
+		Email:                   o.Emails,
+		PhoneNumber:             o.PhoneNumbers,
+		StructuredPostalAddress: o.Addresses,
+		IM:                      o.IMs,
+		Organization:            o.Organizations,
+		ExtendedProperty:        o.ExtendedProperties,
+		UserDefinedField:        o.UserDefinedFields,
+		GroupMembership:         o.GroupMemberships,
+
+		deleted:   o.Deleted,
+		draft:     o.Draft,
+		id:        o.ID,
+		etag:      o.Etag,
+		content:   o.Content,
+		title:     o.Title,
+		titleType: o.TitleType,
+	}
+	if o.Birthday != nil {
+		c.Birthday = *o.Birthday
+	}
+	if o.Updated != nil {
+		c.updated = *o.Updated
+	}
+	if o.Edited != nil {
+		c.edited = *o.Edited
+	}
+	if o.Links != nil {
+		c.selfLink, c.editLink, c.photoLink = o.Links.Self, o.Links.Edit, o.Links.Photo
+	}
+	return nil
+}
+
+// jsonTime returns nil for the zero time, which is omitted from the JSON.
+func jsonTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
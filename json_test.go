@@ -0,0 +1,49 @@
+package contacts
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestContactKindJSON(t *testing.T) {
+	var c ContactKind
+	extra := `<title>Elizabeth Bennet</title>
+  <content>My good friend, Liz.</content>
+  <link rel='http://schemas.google.com/contacts/2008/rel#photo' type='image/*' href='https://www.google.com/m8/feeds/photos/media/example.com/abc'/>
+  <gd:name><gd:givenName>Elizabeth</gd:givenName><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>
+  <gd:email rel='http://schemas.google.com/g/2005#work' primary='true' address='liz@gmail.com'/>
+  <gd:phoneNumber rel='http://schemas.google.com/g/2005#mobile'>(206) 555-1212</gd:phoneNumber>
+  <gd:structuredPostalAddress rel='http://schemas.google.com/g/2005#work'><gd:city>Mountain View</gd:city></gd:structuredPostalAddress>
+  <gd:im rel='http://schemas.google.com/g/2005#home' protocol='http://schemas.google.com/g/2005#GOOGLE_TALK' address='liz@gmail.com'/>
+  <gd:extendedProperty name='source' value='crm'/>`
+	if err := xml.Unmarshal([]byte(testEntry("abc", `"v1"`, extra)), &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json marshal error: %v", err)
+	}
+	for _, key := range []string{`"id":`, `"etag":`, `"updated":`, `"name":`, `"emails":`, `"phoneNumbers":`, `"addresses":`, `"ims":`, `"extendedProperties":`, `"links":`} {
+		if !strings.Contains(string(b), key) {
+			t.Errorf("expect %s in the JSON, got %s", key, b)
+		}
+	}
+
+	var o ContactKind
+	if err = json.Unmarshal(b, &o); err != nil {
+		t.Fatalf("json unmarshal error: %v", err)
+	}
+	if o.GetID() != c.GetID() || o.GetFullID() != c.GetFullID() || o.GetEtag() != c.GetEtag() ||
+		!o.GetUpdated().Equal(c.GetUpdated()) || !o.GetEdited().Equal(c.GetEdited()) {
+		t.Fatalf("metadata not match: expect %s %s %v, got %s %s %v", c.GetFullID(), c.GetEtag(), c.GetUpdated(), o.GetFullID(), o.GetEtag(), o.GetUpdated())
+	}
+	if o.GetEditLink() != c.GetEditLink() || o.GetPhotoLink() != c.GetPhotoLink() || c.GetPhotoLink() == "" {
+		t.Fatalf("links not match: expect %q %q, got %q %q", c.GetEditLink(), c.GetPhotoLink(), o.GetEditLink(), o.GetPhotoLink())
+	}
+	if o.GetTitle() != c.GetTitle() || !o.Equal(c) {
+		t.Fatalf("contacts not match after a round trip:\n%+v\n%+v", c, o)
+	}
+}
@@ -0,0 +1,111 @@
+package contacts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+)
+
+// maxNDJSONLine is the size limit of a line of ImportNDJSON.
+const maxNDJSONLine = 1 << 20
+
+// ExportNDJSON writes the contacts of the feed to w as newline-delimited
+// JSON, one contact per line in the form of ContactKind.MarshalJSON, and
+// returns the number of contacts written.
+// The feed is written page by page as it's fetched, so the contacts are
+// never all held in memory.
+func (s *service) ExportNDJSON(ctx context.Context, w io.Writer, projection string, queries ...func(url.Values)) (int, error) {
+	n := 0
+	enc := json.NewEncoder(w)
+	_, err := s.listPages(ctx, "ExportNDJSON", projection, "", queries, func(page []*ContactKind) error {
+		for _, c := range page {
+			if err := enc.Encode(c); err != nil {
+				return fmt.Errorf("ExportNDJSON error: %w", err)
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// ImportNDJSON reads contacts from r, one JSON contact per line in the
+// format of ExportNDJSON, and saves them, running at most concurrency
+// requests at once. A line with an id updates that contact, conditionally
+// if it has an etag too, otherwise overwriting it with the '*' etag; a
+// line without an id creates a new contact.
+// Lines that can't be decoded and contacts that fail to save are counted
+// as failed, and the import goes on. err reports a failure to read r, or
+// the context error if ctx is done before all lines are read.
+func (s *service) ImportNDJSON(ctx context.Context, r io.Reader, concurrency int) (created, updated, failed int, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	count := func(n *int) {
+		mu.Lock()
+		*n++
+		mu.Unlock()
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxNDJSONLine)
+scan:
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var c ContactKind
+		if err := json.Unmarshal(line, &c); err != nil || bytes.Equal(line, []byte("null")) {
+			count(&failed)
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break scan
+		}
+		wg.Add(1)
+		go func(c *ContactKind) {
+			defer func() { <-sem; wg.Done() }()
+			if c.GetFullID() == "" {
+				if _, err := s.CreateContact(ctx, c); err != nil {
+					count(&failed)
+					return
+				}
+				count(&created)
+				return
+			}
+			etag := c.GetEtag()
+			if etag == "" {
+				etag = "*"
+			}
+			if _, err := s.UpdateContact(ctx, c.GetID(), etag, c); err != nil {
+				count(&failed)
+				return
+			}
+			count(&updated)
+		}(&c)
+	}
+	wg.Wait()
+
+	if err = sc.Err(); err != nil {
+		return created, updated, failed, fmt.Errorf("ImportNDJSON error: %w", err)
+	}
+	if err = ctx.Err(); err != nil {
+		return created, updated, failed, fmt.Errorf("ImportNDJSON error: %w", err)
+	}
+	return created, updated, failed, nil
+}
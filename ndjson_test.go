@@ -0,0 +1,100 @@
+package contacts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExportNDJSON(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start-index") == "2" {
+			writeFeed(w, `"feed"`, "", testEntry("def", `"v2"`, `<gd:name><gd:fullName>Jane Bennet</gd:fullName></gd:name>`))
+			return
+		}
+		writeFeed(w, `"feed"`, "https://www.google.com/m8/feeds/contacts/example.com/full?start-index=2",
+			testEntry("abc", `"v1"`, `<gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>`))
+	}))
+
+	var buf bytes.Buffer
+	n, err := s.ExportNDJSON(context.Background(), &buf, "")
+	if err != nil {
+		t.Fatalf("ExportNDJSON error: %v", err)
+	}
+
+	var lines []ContactKind
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var l ContactKind
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatalf("line %d: json unmarshal error: %v", len(lines), err)
+		}
+		lines = append(lines, l)
+	}
+	if n != 2 || len(lines) != 2 {
+		t.Fatalf("expect 2 contacts in 2 lines, got %d in %d lines", n, len(lines))
+	}
+	if lines[0].GetID() != "abc" || lines[0].Name.FullName != "Elizabeth Bennet" || lines[1].GetID() != "def" || lines[1].GetEtag() != `"v2"` {
+		t.Fatalf("lines not match: %+v %+v", lines[0], lines[1])
+	}
+}
+
+func TestImportNDJSON(t *testing.T) {
+	var mu sync.Mutex
+	var creates, missing int
+	puts := map[string]string{}
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost:
+			creates++
+			w.WriteHeader(http.StatusCreated)
+			writeEntry(w, "new", `"v1"`)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/full/abc"):
+			writeEntry(w, "abc", `"v1"`)
+		case r.Method == http.MethodPut && (strings.HasSuffix(r.URL.Path, "/full/abc") || strings.HasSuffix(r.URL.Path, "/full/def")):
+			id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			puts[id] = r.Header.Get("If-Match")
+			writeEntry(w, id, `"v2"`)
+		case strings.HasSuffix(r.URL.Path, "/full/missing"):
+			missing++
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	in := strings.Join([]string{
+		`{"id":"abc","etag":"\"v1\"","Name":{"FullName":"Elizabeth Bennet"}}`,
+		`{"id":"def","Name":{"FullName":"Charlotte Lucas"}}`,
+		`{"Name":{"FullName":"Jane Bennet"}}`,
+		`{"Name":{"FullName":"Mary Bennet"}`,
+		``,
+		`{"Name":{"FullName":"Kitty Bennet"}}`,
+		`{"id":"missing","etag":"\"v1\"","Name":{"FullName":"Lydia Bennet"}}`,
+	}, "\n")
+
+	created, updated, failed, err := s.ImportNDJSON(context.Background(), strings.NewReader(in), 2)
+	if err != nil {
+		t.Fatalf("ImportNDJSON error: %v", err)
+	}
+	if created != 2 || updated != 2 || failed != 2 {
+		t.Fatalf("expect 2 created, 2 updated and 2 failed, got %d, %d and %d", created, updated, failed)
+	}
+	if creates != 2 || len(puts) != 2 {
+		t.Fatalf("expect 2 creates and 2 updates sent, got %d and %v", creates, puts)
+	}
+	if puts["abc"] != `"v1"` || puts["def"] != "*" {
+		t.Fatalf("expect the update of abc conditional and def unconditional, got %v", puts)
+	}
+	// the missing contact fails because the server doesn't find it.
+	if missing != 1 {
+		t.Fatalf("expect the missing contact looked up once, got %d", missing)
+	}
+}
@@ -0,0 +1,201 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var photosBaseURL = "https://www.google.com/m8/feeds/photos/media/%s"
+
+// GetContactPhoto downloads the photo of the contact id. It returns the
+// image and its content type; the caller closes the image.
+// It returns ErrNotFound if the contact has no photo.
+func (s *service) GetContactPhoto(ctx context.Context, id string) (io.ReadCloser, string, error) {
+	return s.getContactPhoto(ctx, "GetContactPhoto", id, "")
+}
+
+// GetContactPhotoIfNoneMatch downloads the photo of the contact id like
+// GetContactPhoto, unless the photo still has the given etag, in which
+// case it returns ErrNotModified.
+func (s *service) GetContactPhotoIfNoneMatch(ctx context.Context, id, etag string) (io.ReadCloser, string, error) {
+	return s.getContactPhoto(ctx, "GetContactPhotoIfNoneMatch", id, etag)
+}
+
+func (s *service) getContactPhoto(ctx context.Context, method, id, etag string) (io.ReadCloser, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.photoURL(id), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s error: could not create a HTTP request: %w", method, err)
+	}
+	if etag != "" && etag != "*" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	res, err := s.base.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s error: %w", method, err)
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Body, res.Header.Get("Content-Type"), nil
+	case http.StatusNotModified:
+		res.Body.Close()
+		return nil, "", fmt.Errorf("%s error: %w", method, ErrNotModified)
+	default:
+		defer res.Body.Close()
+		return nil, "", fmt.Errorf("%s error: %w", method, newAPIError(res))
+	}
+}
+
+// SetContactPhoto uploads r as the photo of the contact id, replacing the
+// current one whatever its version, and returns the etag of the new photo.
+// contentType is the image type, e.g. "image/jpeg"; other types are
+// rejected before anything is sent. It returns ErrConflict if the server
+// reports a version conflict.
+func (s *service) SetContactPhoto(ctx context.Context, id string, r io.Reader, contentType string) (string, error) {
+	return s.setContactPhoto(ctx, "SetContactPhoto", id, "*", r, contentType)
+}
+
+// SetContactPhotoIf uploads r as the photo of the contact id like
+// SetContactPhoto, only if the current photo has the given etag, otherwise
+// it returns ErrConflict. If etag equals to '*', any version is replaced.
+func (s *service) SetContactPhotoIf(ctx context.Context, id, etag string, r io.Reader, contentType string) (string, error) {
+	if etag == "" {
+		return "", fmt.Errorf("SetContactPhotoIf error: no etag, '*' replaces any version")
+	}
+	return s.setContactPhoto(ctx, "SetContactPhotoIf", id, etag, r, contentType)
+}
+
+func (s *service) setContactPhoto(ctx context.Context, method, id, etag string, r io.Reader, contentType string) (string, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mt, "image/") {
+		return "", fmt.Errorf("%s error: not an image content type %q", method, contentType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.photoURL(id), r)
+	if err != nil {
+		return "", fmt.Errorf("%s error: could not create a HTTP request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("If-Match", etag)
+
+	res, err := s.base.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s error: %w", method, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return res.Header.Get("ETag"), nil
+	default:
+		// HTTP 412 means the photo changed since etag, and matches ErrConflict.
+		return "", fmt.Errorf("%s error: %w", method, newAPIError(res))
+	}
+}
+
+// DeleteContactPhoto deletes the photo of the contact id. If etag is
+// provided, only the version is met will be deleted, otherwise it returns
+// ErrConflict. If etag is empty or equals to '*', any version is deleted.
+// It returns ErrNotFound if the contact has no photo.
+func (s *service) DeleteContactPhoto(ctx context.Context, id, etag string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.photoURL(id), nil)
+	if err != nil {
+		return fmt.Errorf("DeleteContactPhoto error: could not create a HTTP request: %w", err)
+	}
+	if etag == "" {
+		etag = "*"
+	}
+	req.Header.Set("If-Match", etag)
+
+	res, err := s.base.Do(req)
+	if err != nil {
+		return fmt.Errorf("DeleteContactPhoto error: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("DeleteContactPhoto error: %w", newAPIError(res))
+	}
+}
+
+// ExportPhotos downloads the photos of ids, running at most concurrency
+// downloads at once, and returns the images keyed by id. Contacts without
+// a photo are left out. The other failures are joined in the error.
+// If ctx is done, no more downloads are started; the ones in flight are
+// waited for, and the photos downloaded so far are returned with the
+// context error.
+func (s *service) ExportPhotos(ctx context.Context, ids []string, concurrency int) (map[string][]byte, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		ret  = make(map[string][]byte, len(ids))
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+launch:
+	for _, id := range ids {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break launch
+		}
+		if ctx.Err() != nil {
+			// the context may be done while a slot was free too.
+			<-sem
+			break launch
+		}
+
+		wg.Add(1)
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+			b, err := s.exportPhoto(ctx, id)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrNotFound):
+			case err != nil:
+				errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			default:
+				ret[id] = b
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return ret, fmt.Errorf("ExportPhotos error: %w", err)
+	}
+	return ret, errors.Join(errs...)
+}
+
+// exportPhoto downloads the photo of id for ExportPhotos.
+func (s *service) exportPhoto(ctx context.Context, id string) ([]byte, error) {
+	body, _, err := s.getContactPhoto(ctx, "ExportPhotos", id, "")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("ExportPhotos error: %w", err)
+	}
+	return b, nil
+}
+
+// photoURL returns the photo media URL of the contact id.
+func (s *service) photoURL(id string) string {
+	return fmt.Sprintf(photosBaseURL, s.domain) + "/" + id
+}
@@ -0,0 +1,180 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGetContactPhoto(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/m8/feeds/photos/media/example.com/nophoto":
+			http.NotFound(w, r)
+		case r.URL.Path != "/m8/feeds/photos/media/example.com/abc":
+			t.Errorf("unexpected path %s", r.URL.Path)
+		case r.Header.Get("If-None-Match") == `"photo-1"`:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("ETag", `"photo-1"`)
+			w.Write([]byte("jpeg bytes"))
+		}
+	}))
+
+	body, ct, err := s.GetContactPhoto(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("GetContactPhoto error: %v", err)
+	}
+	b, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || string(b) != "jpeg bytes" || ct != "image/jpeg" {
+		t.Fatalf("expect the jpeg photo, got %q of %s, %v", b, ct, err)
+	}
+
+	if _, _, err = s.GetContactPhoto(context.Background(), "nophoto"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound without a photo, got %v", err)
+	}
+	if _, _, err = s.GetContactPhotoIfNoneMatch(context.Background(), "abc", `"photo-1"`); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("expect ErrNotModified for the same etag, got %v", err)
+	}
+}
+
+func TestSetContactPhoto(t *testing.T) {
+	var puts int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		puts++
+		if r.Method != http.MethodPut || r.Header.Get("If-Match") != "*" || r.Header.Get("GData-Version") != gdataVersion {
+			t.Errorf("expect an overwriting PUT, got %s with If-Match %q", r.Method, r.Header.Get("If-Match"))
+		}
+		if r.Header.Get("Content-Type") != "image/jpeg" {
+			t.Errorf("expect the image content type, got %q", r.Header.Get("Content-Type"))
+		}
+		if r.URL.Path == "/m8/feeds/photos/media/example.com/conflict" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		if string(b) != "jpeg bytes" {
+			t.Errorf("expect the photo uploaded, got %q", b)
+		}
+		w.Header().Set("ETag", `"photo-2"`)
+	}))
+
+	etag, err := s.SetContactPhoto(context.Background(), "abc", strings.NewReader("jpeg bytes"), "image/jpeg")
+	if err != nil {
+		t.Fatalf("SetContactPhoto error: %v", err)
+	}
+	if etag != `"photo-2"` {
+		t.Fatalf("expect the photo etag, got %q", etag)
+	}
+
+	if _, err = s.SetContactPhoto(context.Background(), "conflict", strings.NewReader("jpeg bytes"), "image/jpeg"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict, got %v", err)
+	}
+
+	if _, err = s.SetContactPhoto(context.Background(), "abc", strings.NewReader("<html>"), "text/html"); err == nil {
+		t.Fatalf("expect a non-image content type rejected")
+	}
+	if puts != 2 {
+		t.Fatalf("expect 2 uploads sent, got %d", puts)
+	}
+}
+
+func TestSetContactPhotoIf(t *testing.T) {
+	var matches []string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matches = append(matches, r.Header.Get("If-Match"))
+		switch r.Header.Get("If-Match") {
+		case "*", `"photo-1"`:
+			w.Header().Set("ETag", `"photo-2"`)
+		default:
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	}))
+
+	etag, err := s.SetContactPhotoIf(context.Background(), "abc", `"photo-1"`, strings.NewReader("jpeg bytes"), "image/jpeg")
+	if err != nil || etag != `"photo-2"` {
+		t.Fatalf("expect the photo replaced, got %q, %v", etag, err)
+	}
+	if _, err = s.SetContactPhotoIf(context.Background(), "abc", `"photo-0"`, strings.NewReader("jpeg bytes"), "image/jpeg"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for a stale etag, got %v", err)
+	}
+	if _, err = s.SetContactPhotoIf(context.Background(), "abc", "*", strings.NewReader("jpeg bytes"), "image/jpeg"); err != nil {
+		t.Fatalf("expect the wildcard to force the upload, got %v", err)
+	}
+	if _, err = s.SetContactPhotoIf(context.Background(), "abc", "", strings.NewReader("jpeg bytes"), "image/jpeg"); err == nil {
+		t.Fatalf("expect an empty etag rejected")
+	}
+	if want := []string{`"photo-1"`, `"photo-0"`, "*"}; !reflect.DeepEqual(matches, want) {
+		t.Fatalf("expect If-Match %q, got %q", want, matches)
+	}
+}
+
+func TestDeleteContactPhoto(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("expect DELETE, got %s", r.Method)
+		}
+		switch {
+		case r.URL.Path == "/m8/feeds/photos/media/example.com/nophoto":
+			http.NotFound(w, r)
+		case r.Header.Get("If-Match") == "*" || r.Header.Get("If-Match") == `"photo-1"`:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	}))
+
+	if err := s.DeleteContactPhoto(context.Background(), "abc", "*"); err != nil {
+		t.Fatalf("DeleteContactPhoto error: %v", err)
+	}
+	if err := s.DeleteContactPhoto(context.Background(), "abc", ""); err != nil {
+		t.Fatalf("DeleteContactPhoto error without etag: %v", err)
+	}
+	if err := s.DeleteContactPhoto(context.Background(), "abc", `"photo-0"`); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect ErrConflict for a stale etag, got %v", err)
+	}
+	if err := s.DeleteContactPhoto(context.Background(), "nophoto", "*"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound without a photo, got %v", err)
+	}
+}
+
+func TestExportPhotosCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/m8/feeds/photos/media/example.com/a":
+			w.Write([]byte("a"))
+		case "/m8/feeds/photos/media/example.com/b":
+			// cancel the export while this download is in flight.
+			cancel()
+			<-r.Context().Done()
+		default:
+			w.Write([]byte("c"))
+		}
+	}))
+
+	photos, err := s.ExportPhotos(ctx, []string{"a", "b", "c"}, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect context.Canceled, got %v", err)
+	}
+	if len(photos) != 1 || string(photos["a"]) != "a" {
+		t.Fatalf("expect the photo of a only, got %v", photos)
+	}
+	if requested["/m8/feeds/photos/media/example.com/c"] {
+		t.Fatalf("expect no download started after the cancellation")
+	}
+}
@@ -0,0 +1,147 @@
+package contacts
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var profilesBaseURL = "https://www.google.com/m8/feeds/profiles/domain/%s/full"
+
+// ProfileKind is a domain user's profile from the directory feed.
+// Profiles are managed in the Google Workspace console; the directory feed is read-only.
+type ProfileKind struct {
+	Name                    GDName
+	Email                   []GDEmail
+	PhoneNumber             []GDPhoneNumber
+	StructuredPostalAddress []GDStructuredPostalAddress
+	IM                      []GDIM
+
+	photoLink string
+	id        string
+	updated   time.Time
+	etag      string
+}
+
+// GetPhotoLink returns the photo link of the profile entry.
+func (p ProfileKind) GetPhotoLink() string { return p.photoLink }
+
+// GetID returns the ID of the profile entry.
+func (p ProfileKind) GetID() string {
+	idx := strings.LastIndex(p.id, "/")
+	return p.id[idx+1:]
+}
+
+// GetUpdated returns the last updated time of the profile entry.
+func (p ProfileKind) GetUpdated() time.Time { return p.updated }
+
+// GetEtag returns the etag of the profile entry.
+func (p ProfileKind) GetEtag() string { return p.etag }
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (p *ProfileKind) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type decodeProfileKind struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom entry"`
+		Etag     string   `xml:"etag,attr"`
+		Category struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+		ID                      string                      `xml:"id"`
+		Updated                 time.Time                   `xml:"updated"`
+		Name                    GDName                      `xml:"http://schemas.google.com/g/2005 name"`
+		Email                   []GDEmail                   `xml:"http://schemas.google.com/g/2005 email"`
+		PhoneNumber             []GDPhoneNumber             `xml:"http://schemas.google.com/g/2005 phoneNumber"`
+		StructuredPostalAddress []GDStructuredPostalAddress `xml:"http://schemas.google.com/g/2005 structuredPostalAddress"`
+		IM                      []GDIM                      `xml:"http://schemas.google.com/g/2005 im"`
+		Link                    []Link                      `xml:"http://www.w3.org/2005/Atom link"`
+	}
+
+	var o decodeProfileKind
+	if err := d.DecodeElement(&o, &start); err != nil {
+		return err
+	}
+	const profileTerm = "http://schemas.google.com/contact/2008#profile"
+	if o.Category.Term != profileTerm {
+		return fmt.Errorf("xml type not match: expect %s, got %s", profileTerm, o.Category.Term)
+	}
+
+	p.Name = o.Name
+	p.Email = o.Email
+	p.PhoneNumber = o.PhoneNumber
+	p.StructuredPostalAddress = o.StructuredPostalAddress
+	p.IM = o.IM
+
+	for _, l := range o.Link {
+		if l.Related == "http://schemas.google.com/contacts/2008/rel#photo" {
+			p.photoLink = l.Href
+		}
+	}
+
+	p.id = o.ID
+	p.updated = o.Updated
+	p.etag = o.Etag
+	return nil
+}
+
+// ListDirectoryProfiles retrieves the profiles of the domain users.
+// The directory feed is read-only and needs the directory.readonly scope.
+func (s *service) ListDirectoryProfiles(ctx context.Context, queries ...func(url.Values)) ([]*ProfileKind, error) {
+	u := fmt.Sprintf(profilesBaseURL, s.domain)
+	if len(queries) > 0 {
+		params := url.Values{}
+		if err := applyQueries(params, queries); err != nil {
+			return nil, fmt.Errorf("ListDirectoryProfiles error: %w", err)
+		}
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ListDirectoryProfiles error: could not create a HTTP request: %w", err)
+	}
+
+	type feed struct {
+		Links    []Link        `xml:"link"`
+		Profiles []ProfileKind `xml:"http://www.w3.org/2005/Atom entry"`
+	}
+
+	ret := make([]*ProfileKind, 0, 20)
+	for req != nil {
+		res, err := s.base.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("ListDirectoryProfiles error: %w", err)
+		}
+		if res.StatusCode != http.StatusOK {
+			err = newAPIError(res)
+			res.Body.Close()
+			return nil, fmt.Errorf("ListDirectoryProfiles error: %w", err)
+		}
+
+		f := new(feed)
+		err = xml.NewDecoder(res.Body).Decode(f)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ListDirectoryProfiles error: %w", err)
+		}
+		for i := range f.Profiles {
+			ret = append(ret, &f.Profiles[i])
+		}
+
+		req = nil
+		for _, l := range f.Links {
+			if l.Related == "next" {
+				req, err = http.NewRequestWithContext(ctx, http.MethodGet, l.Href, nil)
+				if err != nil {
+					return nil, fmt.Errorf("ListDirectoryProfiles error: could not create a HTTP request: %w", err)
+				}
+				break
+			}
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,47 @@
+package contacts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListDirectoryProfiles(t *testing.T) {
+	const page = `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005'>
+  <link rel='self' href='https://www.google.com/m8/feeds/profiles/domain/example.com/full'/>
+  %s
+  <entry gd:etag='"profile-%s"'>
+    <id>http://www.google.com/m8/feeds/profiles/domain/example.com/full/%s</id>
+    <category scheme='http://schemas.google.com/g/2005#kind' term='http://schemas.google.com/contact/2008#profile'/>
+    <gd:name><gd:fullName>%s</gd:fullName></gd:name>
+    <gd:email address='%s@example.com' primary='true' rel='http://schemas.google.com/g/2005#work'/>
+  </entry>
+</feed>`
+	next := `<link rel='next' href='https://www.google.com/m8/feeds/profiles/domain/example.com/full?start-index=2'/>`
+
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/m8/feeds/profiles/domain/example.com/full" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("start-index") == "2" {
+			fmt.Fprintf(w, page, "", "2", "jane", "Jane Bennet", "jane")
+			return
+		}
+		fmt.Fprintf(w, page, next, "1", "liz", "Elizabeth Bennet", "liz")
+	}))
+
+	profiles, err := s.ListDirectoryProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListDirectoryProfiles error: %v", err)
+	}
+	if len(profiles) != 2 {
+		t.Fatalf("expect 2 profiles, got %d", len(profiles))
+	}
+	if profiles[0].GetID() != "liz" || profiles[0].Name.FullName != "Elizabeth Bennet" || profiles[0].GetEtag() != `"profile-1"` {
+		t.Fatalf("profile not match: %s %s %s", profiles[0].GetID(), profiles[0].Name.FullName, profiles[0].GetEtag())
+	}
+	if profiles[1].GetID() != "jane" || len(profiles[1].Email) != 1 || profiles[1].Email[0].Address != "jane@example.com" {
+		t.Fatalf("profile not match: %s %v", profiles[1].GetID(), profiles[1].Email)
+	}
+}
@@ -0,0 +1,63 @@
+package contacts
+
+// relBase is the prefix of the rel values of the gd elements.
+const relBase = "http://schemas.google.com/g/2005#"
+
+// Rel values of gd:email, for GDEmail.Related.
+const (
+	EmailRelHome  = relBase + "home"
+	EmailRelOther = relBase + "other"
+	EmailRelWork  = relBase + "work"
+)
+
+// Rel values of gd:phoneNumber, for GDPhoneNumber.Related.
+const (
+	PhoneRelAssistant   = relBase + "assistant"
+	PhoneRelCallback    = relBase + "callback"
+	PhoneRelCar         = relBase + "car"
+	PhoneRelCompanyMain = relBase + "company_main"
+	PhoneRelFax         = relBase + "fax"
+	PhoneRelHome        = relBase + "home"
+	PhoneRelHomeFax     = relBase + "home_fax"
+	PhoneRelISDN        = relBase + "isdn"
+	PhoneRelMain        = relBase + "main"
+	PhoneRelMobile      = relBase + "mobile"
+	PhoneRelOther       = relBase + "other"
+	PhoneRelOtherFax    = relBase + "other_fax"
+	PhoneRelPager       = relBase + "pager"
+	PhoneRelRadio       = relBase + "radio"
+	PhoneRelTelex       = relBase + "telex"
+	PhoneRelTTYTDD      = relBase + "tty_tdd"
+	PhoneRelWork        = relBase + "work"
+	PhoneRelWorkFax     = relBase + "work_fax"
+	PhoneRelWorkMobile  = relBase + "work_mobile"
+	PhoneRelWorkPager   = relBase + "work_pager"
+)
+
+// Rel values of gd:im, for GDIM.Related.
+const (
+	IMRelHome       = relBase + "home"
+	IMRelNetmeeting = relBase + "netmeeting"
+	IMRelOther      = relBase + "other"
+	IMRelWork       = relBase + "work"
+)
+
+// Rel values of gd:structuredPostalAddress, for GDStructuredPostalAddress.Related.
+const (
+	AddressRelHome  = relBase + "home"
+	AddressRelOther = relBase + "other"
+	AddressRelWork  = relBase + "work"
+)
+
+// Protocols of gd:im, for GDIM.Protocol.
+const (
+	IMProtocolAIM        = relBase + "AIM"
+	IMProtocolMSN        = relBase + "MSN"
+	IMProtocolYahoo      = relBase + "YAHOO"
+	IMProtocolSkype      = relBase + "SKYPE"
+	IMProtocolQQ         = relBase + "QQ"
+	IMProtocolGoogleTalk = relBase + "GOOGLE_TALK"
+	IMProtocolICQ        = relBase + "ICQ"
+	IMProtocolJabber     = relBase + "JABBER"
+	IMProtocolNetmeeting = relBase + "NETMEETING"
+)
@@ -0,0 +1,63 @@
+package contacts
+
+import "testing"
+
+func TestRelConstants(t *testing.T) {
+	for _, tc := range []struct{ rel, want string }{
+		{EmailRelHome, "http://schemas.google.com/g/2005#home"},
+		{EmailRelOther, "http://schemas.google.com/g/2005#other"},
+		{EmailRelWork, "http://schemas.google.com/g/2005#work"},
+
+		{PhoneRelAssistant, "http://schemas.google.com/g/2005#assistant"},
+		{PhoneRelCallback, "http://schemas.google.com/g/2005#callback"},
+		{PhoneRelCar, "http://schemas.google.com/g/2005#car"},
+		{PhoneRelCompanyMain, "http://schemas.google.com/g/2005#company_main"},
+		{PhoneRelFax, "http://schemas.google.com/g/2005#fax"},
+		{PhoneRelHome, "http://schemas.google.com/g/2005#home"},
+		{PhoneRelHomeFax, "http://schemas.google.com/g/2005#home_fax"},
+		{PhoneRelISDN, "http://schemas.google.com/g/2005#isdn"},
+		{PhoneRelMain, "http://schemas.google.com/g/2005#main"},
+		{PhoneRelMobile, "http://schemas.google.com/g/2005#mobile"},
+		{PhoneRelOther, "http://schemas.google.com/g/2005#other"},
+		{PhoneRelOtherFax, "http://schemas.google.com/g/2005#other_fax"},
+		{PhoneRelPager, "http://schemas.google.com/g/2005#pager"},
+		{PhoneRelRadio, "http://schemas.google.com/g/2005#radio"},
+		{PhoneRelTelex, "http://schemas.google.com/g/2005#telex"},
+		{PhoneRelTTYTDD, "http://schemas.google.com/g/2005#tty_tdd"},
+		{PhoneRelWork, "http://schemas.google.com/g/2005#work"},
+		{PhoneRelWorkFax, "http://schemas.google.com/g/2005#work_fax"},
+		{PhoneRelWorkMobile, "http://schemas.google.com/g/2005#work_mobile"},
+		{PhoneRelWorkPager, "http://schemas.google.com/g/2005#work_pager"},
+
+		{IMRelHome, "http://schemas.google.com/g/2005#home"},
+		{IMRelNetmeeting, "http://schemas.google.com/g/2005#netmeeting"},
+		{IMRelOther, "http://schemas.google.com/g/2005#other"},
+		{IMRelWork, "http://schemas.google.com/g/2005#work"},
+
+		{AddressRelHome, "http://schemas.google.com/g/2005#home"},
+		{AddressRelOther, "http://schemas.google.com/g/2005#other"},
+		{AddressRelWork, "http://schemas.google.com/g/2005#work"},
+
+		{IMProtocolAIM, "http://schemas.google.com/g/2005#AIM"},
+		{IMProtocolMSN, "http://schemas.google.com/g/2005#MSN"},
+		{IMProtocolYahoo, "http://schemas.google.com/g/2005#YAHOO"},
+		{IMProtocolSkype, "http://schemas.google.com/g/2005#SKYPE"},
+		{IMProtocolQQ, "http://schemas.google.com/g/2005#QQ"},
+		{IMProtocolGoogleTalk, "http://schemas.google.com/g/2005#GOOGLE_TALK"},
+		{IMProtocolICQ, "http://schemas.google.com/g/2005#ICQ"},
+		{IMProtocolJabber, "http://schemas.google.com/g/2005#JABBER"},
+		{IMProtocolNetmeeting, "http://schemas.google.com/g/2005#NETMEETING"},
+	} {
+		if tc.rel != tc.want {
+			t.Errorf("expect %s, got %s", tc.want, tc.rel)
+		}
+	}
+
+	// the constants are untyped, so they drop into the Related fields.
+	_ = ContactKind{
+		Email:                   []GDEmail{{Address: "liz@gmail.com", Related: EmailRelWork}},
+		PhoneNumber:             []GDPhoneNumber{{DialNumber: "(206)555-1212", Related: PhoneRelMobile}},
+		IM:                      []GDIM{{Address: "liz@gmail.com", Related: IMRelHome, Protocol: IMProtocolGoogleTalk}},
+		StructuredPostalAddress: []GDStructuredPostalAddress{{City: "Mountain View", Related: AddressRelWork}},
+	}
+}
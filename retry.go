@@ -0,0 +1,126 @@
+package contacts
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// BackoffStrategy is how the delay between retries grows, see WithBackoff.
+type BackoffStrategy int
+
+// Backoff strategies.
+const (
+	// ExponentialFullJitter waits a random delay between zero and the
+	// exponential delay, so that clients failing together don't retry together.
+	ExponentialFullJitter BackoffStrategy = iota
+
+	// ExponentialFixed waits exactly the exponential delay.
+	ExponentialFixed
+)
+
+// retryPolicy is how the transport retries failed requests.
+// The zero value doesn't retry.
+type retryPolicy struct {
+	// attempts is the number of tries of a request, including the first one.
+	attempts int
+	// base is the delay before the first retry; it doubles for each retry.
+	base     time.Duration
+	strategy BackoffStrategy
+	// rand returns a number in [0, 1) for the jitter. nil uses math/rand.
+	rand func() float64
+	// post retries POST requests too, see WithRetryPost.
+	post bool
+}
+
+// backoff returns the delay before the n-th retry, 1-based.
+func (p retryPolicy) backoff(n int) time.Duration {
+	d := p.base << (n - 1)
+	if p.strategy == ExponentialFixed {
+		return d
+	}
+
+	r := p.rand
+	if r == nil {
+		r = rand.Float64
+	}
+	return time.Duration(r() * float64(d))
+}
+
+// idempotent reports whether req may be sent again without changing the
+// result: GET and HEAD, and the PUT and DELETE of a given version, which
+// fail with HTTP 412 once applied. POST only if the policy allows it, as a
+// create the server committed before failing would be duplicated.
+func (p retryPolicy) idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPut, http.MethodDelete:
+		return req.Header.Get("If-Match") != ""
+	case http.MethodPost:
+		return p.post
+	default:
+		return false
+	}
+}
+
+// retryable reports whether a request that ended with res and err may
+// succeed if it's sent again.
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// roundTrip sends req, retrying it by the policy. It doesn't wait past the
+// deadline of the request context: when the next backoff would end after
+// it, the last response or error is returned right away.
+func (p retryPolicy) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	res, err := base.RoundTrip(req)
+	if !p.idempotent(req) {
+		return res, err
+	}
+	for n := 1; n < p.attempts && retryable(req, res, err); n++ {
+		delay := p.backoff(n)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+		if req.Body != nil && req.GetBody == nil {
+			// the body can't be sent again.
+			break
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, err
+		case <-timer.C:
+		}
+
+		next := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				break
+			}
+			next.Body = body
+		}
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		req = next
+		res, err = base.RoundTrip(req)
+	}
+	return res, err
+}
@@ -0,0 +1,104 @@
+package contacts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRetry(t *testing.T) {
+	var bodies []string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		writeEntry(w, "abc", `"v1"`)
+	}), WithRetry(3, time.Millisecond), WithRetryPost())
+
+	c, err := s.CreateContact(context.Background(), &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}})
+	if err != nil {
+		t.Fatalf("CreateContact error: %v", err)
+	}
+	if c.GetID() != "abc" || len(bodies) != 3 {
+		t.Fatalf("expect success on the 3rd try, got %d tries", len(bodies))
+	}
+	if bodies[0] == "" || bodies[2] != bodies[0] {
+		t.Fatalf("expect the body re-sent on retries, got %q", bodies)
+	}
+}
+
+func TestWithRetryPostOnce(t *testing.T) {
+	var tries int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tries++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), WithRetry(3, time.Millisecond))
+
+	if _, err := s.CreateContact(context.Background(), &ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}}); err == nil {
+		t.Fatalf("expect the 503 error")
+	}
+	if tries != 1 {
+		t.Fatalf("expect a POST sent exactly once, got %d tries", tries)
+	}
+
+	// the PUT of a given version fails once applied, so it's retried.
+	tries = 0
+	req, _ := http.NewRequest(http.MethodPut, "https://www.google.com/m8/feeds/contacts/example.com/full/abc", nil)
+	req.Header.Set("If-Match", `"v1"`)
+	if res, err := s.base.Do(req); err == nil {
+		res.Body.Close()
+	}
+	if tries != 3 {
+		t.Fatalf("expect a conditional PUT retried, got %d tries", tries)
+	}
+}
+
+func TestWithRetryDeadline(t *testing.T) {
+	var tries int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tries++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), WithRetry(5, 10*time.Second), WithBackoff(ExponentialFixed))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := s.GetContact(ctx, "abc", "", "")
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expect the last 503 error, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second || tries != 1 {
+		t.Fatalf("expect to return without sleeping, took %s and %d tries", d, tries)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		strategy BackoffStrategy
+		rand     float64
+		min, max time.Duration
+	}{
+		{ExponentialFixed, 0, 400 * time.Millisecond, 400 * time.Millisecond},
+		{ExponentialFixed, 0.99, 400 * time.Millisecond, 400 * time.Millisecond},
+		{ExponentialFullJitter, 0, 0, 0},
+		{ExponentialFullJitter, 0.5, 200 * time.Millisecond, 200 * time.Millisecond},
+		{ExponentialFullJitter, 0.99, 0, 400 * time.Millisecond},
+	} {
+		r := tc.rand
+		p := retryPolicy{base: 100 * time.Millisecond, strategy: tc.strategy, rand: func() float64 { return r }}
+		if d := p.backoff(3); d < tc.min || d > tc.max {
+			t.Errorf("strategy %d, rand %v: expect the 3rd delay in [%s, %s], got %s", tc.strategy, tc.rand, tc.min, tc.max, d)
+		}
+	}
+
+	if _, err := NewService(&http.Client{}, "example.com", "", WithBackoff(BackoffStrategy(9))); err == nil {
+		t.Fatalf("expect error for an unknown strategy")
+	}
+}
@@ -0,0 +1,98 @@
+package contacts
+
+import (
+	"encoding/xml"
+	"net/url"
+	"time"
+)
+
+// WithRSS requests the feed in the RSS format instead of atom.
+// ListContacts maps the RSS items to contacts: the guid is the id, the
+// description is the content, and the GData elements are decoded as in
+// an atom entry.
+func WithRSS() func(url.Values) {
+	return withReturnType("rss")
+}
+
+// rssFeed is a contacts feed in the RSS format.
+type rssFeed struct {
+	Etag    string `xml:"http://schemas.google.com/g/2005 etag,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Links         []Link    `xml:"http://www.w3.org/2005/Atom link"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// updated returns the last build date of the feed, or the zero time if
+// it's missing or malformed.
+func (f rssFeed) updated() time.Time {
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, f.Channel.LastBuildDate); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// entries returns the items as the entries of an atom feed.
+func (f rssFeed) entries() []feedEntry {
+	ret := make([]feedEntry, 0, len(f.Channel.Items))
+	for _, it := range f.Channel.Items {
+		ret = append(ret, feedEntry(it))
+	}
+	return ret
+}
+
+// rssItem is a contact item of an RSS feed. Like feedEntry, an item that
+// fails to decode keeps the error.
+type rssItem feedEntry
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (it *rssItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type decodeRSSItem struct {
+		GUID     string `xml:"guid"`
+		Category []struct {
+			Domain string `xml:"domain,attr"`
+			Term   string `xml:",chardata"`
+		} `xml:"category"`
+		Title       string `xml:"title"`
+		Description string `xml:"description"`
+	}
+
+	toks, err := captureElement(d, start)
+	if err != nil {
+		return err
+	}
+	var o decodeRSSItem
+	if it.err = decodeCaptured(toks, &o); it.err != nil {
+		return nil
+	}
+
+	// the GData elements of an item are the same as in an atom entry, so
+	// the item is decoded as an entry, with its kind category in the atom form.
+	const kindScheme = "http://schemas.google.com/g/2005#kind"
+	name := xml.Name{Space: nsAtom, Local: "entry"}
+	entry := []xml.Token{xml.StartElement{Name: name, Attr: start.Attr}}
+	for _, cat := range o.Category {
+		if cat.Domain == kindScheme {
+			category := xml.Name{Space: nsAtom, Local: "category"}
+			entry = append(entry, xml.StartElement{Name: category, Attr: []xml.Attr{
+				{Name: xml.Name{Local: "scheme"}, Value: kindScheme},
+				{Name: xml.Name{Local: "term"}, Value: cat.Term},
+			}}, xml.EndElement{Name: category})
+			break
+		}
+	}
+	entry = append(entry, toks[1:len(toks)-1]...)
+	entry = append(entry, xml.EndElement{Name: name})
+	if it.err = decodeCaptured(entry, &it.contact); it.err != nil {
+		return nil
+	}
+
+	it.contact.id = o.GUID
+	it.contact.title = o.Title
+	it.contact.content = o.Description
+	return nil
+}
@@ -0,0 +1,51 @@
+package contacts
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestListContactsRSS(t *testing.T) {
+	const feed = `<?xml version='1.0' encoding='UTF-8'?>
+<rss version='2.0' xmlns:atom='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"feed"'>
+  <channel>
+    <lastBuildDate>Fri, 18 Aug 2023 09:54:17 +0000</lastBuildDate>
+    <title>Domain Shared Contacts for example.com</title>
+    <item gd:etag='"v1"'>
+      <guid isPermaLink='false'>http://www.google.com/m8/feeds/contacts/example.com/base/abc</guid>
+      <atom:updated>2023-08-18T09:54:17.202Z</atom:updated>
+      <category domain='http://schemas.google.com/g/2005#kind'>http://schemas.google.com/contact/2008#contact</category>
+      <title>Elizabeth Bennet</title>
+      <description>My good friend, Liz.</description>
+      <atom:link rel='edit' type='application/atom+xml' href='https://www.google.com/m8/feeds/contacts/example.com/full/abc'/>
+      <gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>
+      <gd:email rel='http://schemas.google.com/g/2005#work' address='liz@example.com' primary='true'/>
+    </item>
+  </channel>
+</rss>`
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("alt") != "rss" {
+			t.Errorf("expect alt rss, got %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(feed))
+	}))
+
+	cs, st, err := s.ListContacts(context.Background(), "", "", WithRSS())
+	if err != nil {
+		t.Fatalf("ListContacts error: %v", err)
+	}
+	if st.FeedTitle != "Domain Shared Contacts for example.com" || st.Etag != `"feed"` || st.Updated.IsZero() {
+		t.Fatalf("expect the channel status, got %+v", st)
+	}
+	if len(cs) != 1 {
+		t.Fatalf("expect 1 contact, got %d", len(cs))
+	}
+	c := cs[0]
+	if c.GetID() != "abc" || c.GetTitle() != "Elizabeth Bennet" || c.GetEtag() != `"v1"` || c.GetEditLink() == "" {
+		t.Fatalf("contact not match: %s %s %s %s", c.GetID(), c.GetTitle(), c.GetEtag(), c.GetEditLink())
+	}
+	if c.Name.FullName != "Elizabeth Bennet" || len(c.Email) != 1 || c.Email[0].Address != "liz@example.com" {
+		t.Fatalf("contact data not match: %+v", c)
+	}
+}
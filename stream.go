@@ -0,0 +1,166 @@
+package contacts
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// StreamContacts calls fn for each contact of the feed, in the order of
+// the feed, as the entries are decoded. Unlike ListContacts, neither the
+// contacts nor a whole page are held in memory, so the memory use doesn't
+// depend on the feed size. If fn returns an error, streaming stops and the
+// error is returned as is. The feed must be in the atom format.
+func (s *service) StreamContacts(ctx context.Context, projection, etag string, fn func(*ContactKind) error, queries ...func(url.Values)) (*QueryStatus, error) {
+	u, err := s.listURL(projection, queries)
+	if err != nil {
+		return nil, fmt.Errorf("StreamContacts error: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("StreamContacts error: could not create a HTTP request: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	st := new(QueryStatus)
+	n := 0
+	for req != nil {
+		res, err := s.base.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("StreamContacts error: %w", err)
+		}
+		if res.StatusCode == http.StatusNotModified {
+			res.Body.Close()
+			return nil, fmt.Errorf("StreamContacts error: %w", ErrNotModified)
+		}
+		if res.StatusCode != http.StatusOK {
+			err = newAPIError(res)
+			res.Body.Close()
+			return nil, fmt.Errorf("StreamContacts error: %w", err)
+		}
+
+		var fnErr error
+		head, err := streamFeed(res.Body, func(e feedEntry) error {
+			n++
+			if e.err != nil {
+				if !s.lenient {
+					return fmt.Errorf("StreamContacts error: %w", e.err)
+				}
+				s.logf("StreamContacts: skipped entry %d of the feed: %v", n-1, e.err)
+				return nil
+			}
+			if s.filter != nil && !s.filter(&e.contact) {
+				return nil
+			}
+			fnErr = fn(&e.contact)
+			return fnErr
+		})
+		res.Body.Close()
+		if fnErr != nil {
+			return nil, fnErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("StreamContacts error: %w", err)
+		}
+
+		req = nil
+		next := head.next
+		if next == "" && s.linkHeader {
+			next = nextLinkHeader(res.Header)
+		}
+		if next != "" {
+			if req, err = http.NewRequestWithContext(ctx, http.MethodGet, next, nil); err != nil {
+				return nil, fmt.Errorf("StreamContacts error: could not create a HTTP request: %w", err)
+			}
+			continue
+		}
+		st.Etag = head.etag
+		st.Updated = head.updated
+		st.FeedTitle = head.title
+	}
+
+	return st, nil
+}
+
+// feedHead is the feed-level data of a page read by streamFeed.
+type feedHead struct {
+	etag    string
+	updated time.Time
+	title   string
+	next    string
+}
+
+// streamFeed reads a contacts feed from r token by token, calling entry for
+// each entry as it's decoded, and returns the feed-level data.
+// It stops at the first error of entry.
+func streamFeed(r io.Reader, entry func(feedEntry) error) (feedHead, error) {
+	var head feedHead
+	dec := xml.NewDecoder(r)
+	for depth := 0; ; {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return head, nil
+		}
+		if err != nil {
+			return head, err
+		}
+
+		switch t := tok.(type) {
+		case xml.EndElement:
+			depth--
+			continue
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				if t.Name.Space != nsAtom || t.Name.Local != "feed" {
+					return head, fmt.Errorf("expected element type <feed> but have <%s>", t.Name.Local)
+				}
+				for _, a := range t.Attr {
+					if a.Name.Local == "etag" {
+						head.etag = a.Value
+					}
+				}
+				continue
+			}
+			if depth != 2 {
+				continue
+			}
+
+			// the children of the feed are decoded whole, up to their end element.
+			depth--
+			switch {
+			case t.Name.Space == nsAtom && t.Name.Local == "entry":
+				var e feedEntry
+				if err = e.UnmarshalXML(dec, t); err != nil {
+					return head, err
+				}
+				if err = entry(e); err != nil {
+					return head, err
+				}
+			case t.Name.Space == nsAtom && t.Name.Local == "link":
+				var l Link
+				if err = dec.DecodeElement(&l, &t); err != nil {
+					return head, err
+				}
+				if l.Related == "next" {
+					head.next = l.Href
+				}
+			case t.Name.Space == nsAtom && t.Name.Local == "updated":
+				err = dec.DecodeElement(&head.updated, &t)
+			case t.Name.Space == nsAtom && t.Name.Local == "title":
+				err = dec.DecodeElement(&head.title, &t)
+			default:
+				err = dec.Skip()
+			}
+			if err != nil {
+				return head, err
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"testing"
+)
+
+func TestStreamContactsBoundedMemory(t *testing.T) {
+	const entries = 20000
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<feed xmlns='http://www.w3.org/2005/Atom' xmlns:gd='http://schemas.google.com/g/2005' gd:etag='"feed"'>
+  <updated>2023-08-18T09:54:17.202Z</updated>
+  <title>Domain Shared Contacts for example.com</title>
+`)
+		for i := 0; i < entries; i++ {
+			fmt.Fprint(w, testEntry(fmt.Sprint(i), `"v1"`, `<gd:name><gd:fullName>Elizabeth Bennet</gd:fullName></gd:name>
+  <gd:email rel='http://schemas.google.com/g/2005#work' address='liz@example.com' primary='true'/>`))
+		}
+		fmt.Fprint(w, `</feed>`)
+	}))
+
+	var ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	base, peak := ms.HeapAlloc, ms.HeapAlloc
+
+	n := 0
+	st, err := s.StreamContacts(context.Background(), "", "", func(c *ContactKind) error {
+		if c.GetID() != fmt.Sprint(n) {
+			return fmt.Errorf("expect contact %d, got %s", n, c.GetID())
+		}
+		n++
+		if n%2000 == 0 {
+			runtime.GC()
+			runtime.ReadMemStats(&ms)
+			if ms.HeapAlloc > peak {
+				peak = ms.HeapAlloc
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamContacts error: %v", err)
+	}
+	if n != entries || st.Etag != `"feed"` || st.FeedTitle != "Domain Shared Contacts for example.com" {
+		t.Fatalf("expect %d contacts and the feed status, got %d and %+v", entries, n, st)
+	}
+	// the decoded feed would take several megabytes.
+	if peak-base > 2<<20 {
+		t.Fatalf("expect the live heap bounded, grew by %d bytes", peak-base)
+	}
+}
+
+func TestStreamContactsStop(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, ""), testEntry("def", `"v1"`, ""))
+	}))
+
+	stop := errors.New("stop")
+	n := 0
+	_, err := s.StreamContacts(context.Background(), "", "", func(c *ContactKind) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Fatalf("expect the error of fn after 1 contact, got %v after %d", err, n)
+	}
+}
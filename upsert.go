@@ -0,0 +1,107 @@
+package contacts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// UpsertByEmail saves contacts, running at most concurrency requests at
+// once. A contact whose primary email is the primary email of an existing
+// contact updates it; any other contact is created. The existing contacts
+// are listed once up front to index their primary emails, ignoring
+// WithFilter, so that the contacts it leaves out aren't created again.
+// Of the contacts sharing a primary email, only the last one is saved.
+// The errors are keyed by the lowercased primary email of the contact, or
+// by its index in contacts if it has none.
+func (s *service) UpsertByEmail(ctx context.Context, contacts []*ContactKind, concurrency int) (created, updated int, errs map[string]error) {
+	errs = make(map[string]error)
+	unfiltered := *s
+	unfiltered.filter = nil
+	existing, _, err := unfiltered.ListContacts(ctx, "full", "")
+	if err != nil {
+		for i, c := range contacts {
+			errs[upsertKey(i, c)] = fmt.Errorf("UpsertByEmail error: %w", err)
+		}
+		return 0, 0, errs
+	}
+	index := make(map[string]*ContactKind, len(existing))
+	for _, c := range existing {
+		if email := primaryEmail(c); email != "" {
+			index[email] = c
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	done := func(key string, n *int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs[key] = err
+			return
+		}
+		*n++
+	}
+
+	// the contacts sharing an email would race to create it twice.
+	last := make(map[string]int, len(contacts))
+	for i, c := range contacts {
+		last[upsertKey(i, c)] = i
+	}
+
+	for i, c := range contacts {
+		key := upsertKey(i, c)
+		if last[key] != i {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			done(key, nil, fmt.Errorf("UpsertByEmail error: %w", ctx.Err()))
+			continue
+		}
+
+		wg.Add(1)
+		go func(key string, c *ContactKind) {
+			defer func() { <-sem; wg.Done() }()
+			if old, ok := index[primaryEmail(c)]; ok {
+				_, err := s.UpdateContact(ctx, old.GetID(), old.GetEtag(), c)
+				done(key, &updated, err)
+				return
+			}
+			_, err := s.CreateContact(ctx, c)
+			done(key, &created, err)
+		}(key, c)
+	}
+	wg.Wait()
+
+	return created, updated, errs
+}
+
+// primaryEmail returns the lowercased address of the primary email of c,
+// or empty if it has none.
+func primaryEmail(c *ContactKind) string {
+	for _, m := range c.Email {
+		if m.Primary {
+			return strings.ToLower(strings.TrimSpace(m.Address))
+		}
+	}
+	return ""
+}
+
+// upsertKey returns the key of the i-th contact of UpsertByEmail in its errors.
+func upsertKey(i int, c *ContactKind) string {
+	if email := primaryEmail(c); email != "" {
+		return email
+	}
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,116 @@
+package contacts
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpsertByEmail(t *testing.T) {
+	var mu sync.Mutex
+	var lists, creates, puts int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/full"):
+			lists++
+			writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, `<gd:email address='Liz@example.com' primary='true' rel='http://schemas.google.com/g/2005#work'/>`))
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/full/abc"):
+			writeEntry(w, "abc", `"v1"`)
+		case r.Method == http.MethodPost:
+			creates++
+			w.WriteHeader(http.StatusCreated)
+			writeEntry(w, "new", `"v1"`)
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/full/abc"):
+			if r.Header.Get("If-Match") != `"v1"` {
+				t.Errorf("expect If-Match %q, got %q", `"v1"`, r.Header.Get("If-Match"))
+			}
+			puts++
+			writeEntry(w, "abc", `"v2"`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	contacts := []*ContactKind{
+		{Name: GDName{FullName: "Elizabeth Bennet"}, Email: []GDEmail{{Address: "liz@example.com", Primary: true, Related: "http://schemas.google.com/g/2005#home"}}},
+		{Name: GDName{FullName: "Jane Bennet"}, Email: []GDEmail{{Address: "jane@example.com", Primary: true, Related: "http://schemas.google.com/g/2005#home"}}},
+	}
+	created, updated, errs := s.UpsertByEmail(context.Background(), contacts, 2)
+	if len(errs) != 0 {
+		t.Fatalf("UpsertByEmail errors: %v", errs)
+	}
+	if created != 1 || updated != 1 {
+		t.Fatalf("expect 1 created and 1 updated, got %d and %d", created, updated)
+	}
+	if lists != 1 || creates != 1 || puts != 1 {
+		t.Fatalf("expect 1 list, 1 create and 1 update sent, got %d, %d and %d", lists, creates, puts)
+	}
+}
+
+func TestUpsertByEmailDuplicates(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			writeFeed(w, `"feed"`, "")
+		case http.MethodPost:
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			w.WriteHeader(http.StatusCreated)
+			writeEntry(w, "new", `"v1"`)
+		}
+	}))
+
+	contacts := []*ContactKind{
+		{Name: GDName{FullName: "Lizzy"}, Email: []GDEmail{{Address: "liz@example.com", Primary: true, Related: "http://schemas.google.com/g/2005#home"}}},
+		{Name: GDName{FullName: "Elizabeth Bennet"}, Email: []GDEmail{{Address: "Liz@Example.com", Primary: true, Related: "http://schemas.google.com/g/2005#home"}}},
+	}
+	created, updated, errs := s.UpsertByEmail(context.Background(), contacts, 2)
+	if len(errs) != 0 {
+		t.Fatalf("UpsertByEmail errors: %v", errs)
+	}
+	if created != 1 || updated != 0 || len(bodies) != 1 || !strings.Contains(bodies[0], "Elizabeth Bennet") {
+		t.Fatalf("expect only the last contact of the email created, got %d created, %d updated: %q", created, updated, bodies)
+	}
+}
+
+func TestUpsertByEmailIgnoresFilter(t *testing.T) {
+	var mu sync.Mutex
+	var creates, puts int
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/full"):
+			writeFeed(w, `"feed"`, "", testEntry("abc", `"v1"`, `<gd:email address='liz@example.com' primary='true' rel='http://schemas.google.com/g/2005#work'/>`))
+		case r.Method == http.MethodGet:
+			writeEntry(w, "abc", `"v1"`)
+		case r.Method == http.MethodPost:
+			creates++
+			w.WriteHeader(http.StatusCreated)
+			writeEntry(w, "new", `"v1"`)
+		case r.Method == http.MethodPut:
+			puts++
+			writeEntry(w, "abc", `"v2"`)
+		}
+	}), WithFilter(func(*ContactKind) bool { return false }))
+
+	contacts := []*ContactKind{
+		{Name: GDName{FullName: "Elizabeth Bennet"}, Email: []GDEmail{{Address: "liz@example.com", Primary: true, Related: "http://schemas.google.com/g/2005#home"}}},
+	}
+	created, updated, errs := s.UpsertByEmail(context.Background(), contacts, 1)
+	if len(errs) != 0 {
+		t.Fatalf("UpsertByEmail errors: %v", errs)
+	}
+	if created != 0 || updated != 1 || creates != 0 || puts != 1 {
+		t.Fatalf("expect the filtered-out contact updated, got %d created and %d updated", created, updated)
+	}
+}
@@ -0,0 +1,278 @@
+package contacts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The documented limits of the extended properties of a contact in the
+// Domain Shared Contacts API. A service created WithExtendedPropertyLimits
+// checks its own limits instead.
+const (
+	// maxExtendedProperties is the number of extended properties a contact may have.
+	maxExtendedProperties = 10
+
+	// maxExtendedPropertySize is the size of an extended property value in bytes.
+	maxExtendedPropertySize = 2048
+)
+
+// propertyLimits are the limits of the extended properties checked by validate.
+type propertyLimits struct {
+	count int
+	size  int
+}
+
+var defaultPropertyLimits = propertyLimits{count: maxExtendedProperties, size: maxExtendedPropertySize}
+
+// Validate checks the contact against the restrictions of the server,
+// so that a bad contact is caught before the server rejects it with HTTP 400.
+// All violations are reported at once, joined with errors.Join.
+// The extended properties are checked against the documented limits, 10
+// properties of at most 2048 bytes each.
+func (c ContactKind) Validate() error { return c.validate(defaultPropertyLimits) }
+
+func (c ContactKind) validate(limits propertyLimits) error {
+	var errs []error
+	errs = append(errs, c.validateExtendedProperties(limits)...)
+	errs = append(errs, c.validatePrimary()...)
+	errs = append(errs, c.validateRelLabel()...)
+	errs = append(errs, c.validateIMAddress()...)
+	errs = append(errs, c.validateAddressEnums()...)
+	if c.Birthday.invalid != "" {
+		errs = append(errs, fmt.Errorf("invalid contact: birthday %q, expect YYYY-MM-DD or --MM-DD", c.Birthday.invalid))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateForCreate checks the contact like Validate, and that it can be
+// created: it must not have an id, which the server assigns.
+// CreateContact checks it before sending the contact, with the extended
+// property limits of the service.
+func (c ContactKind) ValidateForCreate() error { return c.validateForCreate(defaultPropertyLimits) }
+
+func (c ContactKind) validateForCreate(limits propertyLimits) error {
+	var errs []error
+	if c.id != "" {
+		errs = append(errs, fmt.Errorf("invalid contact: create with id %s, the server assigns ids", c.GetID()))
+	}
+	errs = append(errs, c.validate(limits))
+	return errors.Join(errs...)
+}
+
+// ValidateForUpdate checks the contact like Validate, and that it can be
+// sent as an update: a contact read from the server, which has an id, must
+// have its etag or its edit link too. A contact without server metadata is
+// valid, since UpdateContact gets the id and the etag as arguments.
+// UpdateContact checks it before sending the contact, with the extended
+// property limits of the service; with the '*' etag, which overwrites any
+// version, it checks the contact like Validate only.
+func (c ContactKind) ValidateForUpdate() error { return c.validateForUpdate(defaultPropertyLimits) }
+
+func (c ContactKind) validateForUpdate(limits propertyLimits) error {
+	var errs []error
+	if c.id != "" && c.etag == "" && c.editLink == "" {
+		errs = append(errs, fmt.Errorf("invalid contact: update of %s without etag or edit link", c.GetID()))
+	}
+	errs = append(errs, c.validate(limits))
+	return errors.Join(errs...)
+}
+
+// validateExtendedProperties checks the extended property limits.
+func (c ContactKind) validateExtendedProperties(limits propertyLimits) []error {
+	var errs []error
+	if n := len(c.ExtendedProperty); n > limits.count {
+		errs = append(errs, fmt.Errorf("invalid contact: %d extended properties, limit is %d", n, limits.count))
+	}
+
+	keys := make([]string, 0, len(c.ExtendedProperty))
+	for k := range c.ExtendedProperty {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if n := len(c.ExtendedProperty[k]); n > limits.size {
+			errs = append(errs, fmt.Errorf("invalid contact: extended property %q is %d bytes, limit is %d", k, n, limits.size))
+		}
+	}
+	return errs
+}
+
+// validatePrimary checks that each kind of repeated element has at most one
+// primary entry. Different kinds may each have their own primary entry.
+func (c ContactKind) validatePrimary() []error {
+	var errs []error
+	check := func(element string, n int) {
+		if n > 1 {
+			errs = append(errs, fmt.Errorf("invalid contact: %d primary %s, at most 1 is allowed", n, element))
+		}
+	}
+	check("gd:email", countPrimary(c.Email, func(m GDEmail) bool { return m.Primary }))
+	check("gd:phoneNumber", countPrimary(c.PhoneNumber, func(n GDPhoneNumber) bool { return n.Primary }))
+	check("gd:im", countPrimary(c.IM, func(im GDIM) bool { return im.Primary }))
+	check("gd:structuredPostalAddress", countPrimary(c.StructuredPostalAddress, func(a GDStructuredPostalAddress) bool { return a.Primary }))
+	check("gd:organization", countPrimary(c.Organization, func(org GDOrganization) bool { return org.Primary }))
+	return errs
+}
+
+// validateIMAddress checks that the IMs have an address, which the server
+// requires. The decoder keeps an IM without one, e.g. of a malformed import,
+// so that it's reported here rather than dropped.
+func (c ContactKind) validateIMAddress() []error {
+	var errs []error
+	for i, im := range c.IM {
+		if strings.TrimSpace(im.Address) == "" {
+			errs = append(errs, fmt.Errorf("invalid contact: gd:im %d: no address", i))
+		}
+	}
+	return errs
+}
+
+// The documented mailClass and usage values of gd:structuredPostalAddress.
+var (
+	addressMailClasses = map[string]bool{
+		relBase + "both": true, relBase + "letters": true, relBase + "parcels": true, relBase + "neither": true,
+	}
+	addressUsages = map[string]bool{
+		relBase + "general": true, relBase + "local": true,
+	}
+)
+
+// validateAddressEnums checks that the mailClass and usage of the postal
+// addresses, when set, are documented values.
+func (c ContactKind) validateAddressEnums() []error {
+	var errs []error
+	for i, a := range c.StructuredPostalAddress {
+		if a.MailClass != "" && !addressMailClasses[a.MailClass] {
+			errs = append(errs, fmt.Errorf("invalid contact: gd:structuredPostalAddress %d: unknown mailClass %q", i, a.MailClass))
+		}
+		if a.Usage != "" && !addressUsages[a.Usage] {
+			errs = append(errs, fmt.Errorf("invalid contact: gd:structuredPostalAddress %d: unknown usage %q", i, a.Usage))
+		}
+	}
+	return errs
+}
+
+// validateRelLabel checks that the restricted elements have either a rel
+// or a label, but not both, see the restrictions of the Domain Shared
+// Contacts API on ContactKind.
+func (c ContactKind) validateRelLabel() []error {
+	var errs []error
+	check := func(element string, i int, rel, label string) {
+		if err := checkRelLabel(rel, label); err != nil {
+			errs = append(errs, fmt.Errorf("invalid contact: %s %d: %w", element, i, err))
+		}
+	}
+	for i, m := range c.Email {
+		check("gd:email", i, m.Related, m.Label)
+	}
+	for i, im := range c.IM {
+		check("gd:im", i, im.Related, im.Label)
+	}
+	for i, org := range c.Organization {
+		check("gd:organization", i, org.Related, org.Label)
+	}
+	for i, n := range c.PhoneNumber {
+		check("gd:phoneNumber", i, n.Related, n.Label)
+	}
+	for i, a := range c.StructuredPostalAddress {
+		check("gd:structuredPostalAddress", i, a.Related, a.Label)
+	}
+	return errs
+}
+
+// checkRelLabel checks that exactly one of rel and label is set.
+func checkRelLabel(rel, label string) error {
+	switch {
+	case rel != "" && label != "":
+		return errors.New("both rel and label are set")
+	case rel == "" && label == "":
+		return errors.New("neither rel nor label is set")
+	}
+	return nil
+}
+
+// countPrimary counts the entries of s that are primary.
+func countPrimary[T any](s []T, primary func(T) bool) int {
+	n := 0
+	for _, v := range s {
+		if primary(v) {
+			n++
+		}
+	}
+	return n
+}
+
+// relOther is the rel value of entries that are neither home nor work.
+const relOther = "http://schemas.google.com/g/2005#other"
+
+// ApplyRelDefaults sets the rel of the emails, phone numbers, IMs, postal
+// addresses and organizations that have neither a rel nor a label to "other", since the server
+// rejects such entries. Entries with a rel or a label are left unchanged.
+// It's meant for bulk imports of data without relation types.
+func (c *ContactKind) ApplyRelDefaults() {
+	for i := range c.Email {
+		if c.Email[i].Related == "" && c.Email[i].Label == "" {
+			c.Email[i].Related = relOther
+		}
+	}
+	for i := range c.PhoneNumber {
+		if c.PhoneNumber[i].Related == "" && c.PhoneNumber[i].Label == "" {
+			c.PhoneNumber[i].Related = relOther
+		}
+	}
+	for i := range c.IM {
+		if c.IM[i].Related == "" && c.IM[i].Label == "" {
+			c.IM[i].Related = relOther
+		}
+	}
+	for i := range c.StructuredPostalAddress {
+		if c.StructuredPostalAddress[i].Related == "" && c.StructuredPostalAddress[i].Label == "" {
+			c.StructuredPostalAddress[i].Related = relOther
+		}
+	}
+	for i := range c.Organization {
+		if c.Organization[i].Related == "" && c.Organization[i].Label == "" {
+			c.Organization[i].Related = relOther
+		}
+	}
+}
+
+// NormalizeEmails trims the email addresses, lowercases their domain part
+// and removes the duplicate addresses, keeping the first of them. The local
+// part is case-sensitive by the standard, so it's kept as is; see
+// NormalizeEmailsIgnoreCase. A duplicate that is primary makes the kept
+// address primary. c.Email is replaced by a new slice, so the copies of c
+// sharing the former one are unchanged.
+func (c *ContactKind) NormalizeEmails() { c.normalizeEmails(false) }
+
+// NormalizeEmailsIgnoreCase normalizes the email addresses like
+// NormalizeEmails, lowercasing the whole addresses, as most providers
+// ignore the case of the local part.
+func (c *ContactKind) NormalizeEmailsIgnoreCase() { c.normalizeEmails(true) }
+
+func (c *ContactKind) normalizeEmails(lowerLocal bool) {
+	seen := make(map[string]int, len(c.Email))
+	ret := make([]GDEmail, 0, len(c.Email))
+	for _, m := range c.Email {
+		m.Address = strings.TrimSpace(m.Address)
+		if idx := strings.LastIndex(m.Address, "@"); idx >= 0 {
+			local, domain := m.Address[:idx], m.Address[idx:]
+			if lowerLocal {
+				local = strings.ToLower(local)
+			}
+			m.Address = local + strings.ToLower(domain)
+		} else if lowerLocal {
+			m.Address = strings.ToLower(m.Address)
+		}
+
+		if i, ok := seen[m.Address]; ok {
+			ret[i].Primary = ret[i].Primary || m.Primary
+			continue
+		}
+		seen[m.Address] = len(ret)
+		ret = append(ret, m)
+	}
+	c.Email = ret
+}
@@ -0,0 +1,266 @@
+package contacts
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateExtendedPropertyCount(t *testing.T) {
+	c := ContactKind{ExtendedProperty: map[string]string{}}
+	for i := 0; i < maxExtendedProperties; i++ {
+		c.ExtendedProperty[fmt.Sprint("key", i)] = "value"
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("validate error: %v", err)
+	}
+
+	c.ExtendedProperty["one-more"] = "value"
+	if err := c.Validate(); err == nil {
+		t.Fatalf("validate error: expect too many extended properties")
+	}
+}
+
+func TestValidateExtendedPropertySize(t *testing.T) {
+	c := ContactKind{ExtendedProperty: map[string]string{
+		"ok":  "value",
+		"big": strings.Repeat("x", maxExtendedPropertySize+1),
+	}}
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"big"`) {
+		t.Fatalf("validate error: expect oversized property reported, got %v", err)
+	}
+}
+
+func TestWithExtendedPropertyLimits(t *testing.T) {
+	var posts int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusCreated)
+		writeEntry(w, "abc", `"v1"`)
+	})
+	c := &ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		ExtendedProperty: map[string]string{
+			"big": strings.Repeat("x", maxExtendedPropertySize+1),
+		},
+	}
+	for i := 0; i < maxExtendedProperties; i++ {
+		c.ExtendedProperty[fmt.Sprint("key", i)] = "value"
+	}
+
+	if _, err := newTestService(t, h).CreateContact(context.Background(), c); err == nil {
+		t.Fatalf("expect the default limits exceeded")
+	}
+	// the limits of a service don't change the others.
+	s := newTestService(t, h, WithExtendedPropertyLimits(20, 4096))
+	if _, err := s.CreateContact(context.Background(), c); err != nil {
+		t.Fatalf("CreateContact error: limits should be configurable, got %v", err)
+	}
+	if _, err := newTestService(t, h).CreateContact(context.Background(), c); err == nil {
+		t.Fatalf("expect the default limits of another service")
+	}
+	if posts != 1 {
+		t.Fatalf("expect only the contact within the limits sent, got %d", posts)
+	}
+
+	if _, err := NewService(&http.Client{}, "example.com", "", WithExtendedPropertyLimits(-1, 0)); err == nil {
+		t.Fatalf("expect error for negative limits")
+	}
+}
+
+func TestApplyRelDefaults(t *testing.T) {
+	c := ContactKind{
+		Email: []GDEmail{
+			{Address: "liz@gmail.com"},
+			{Address: "liz@example.org", Label: "Club"},
+			{Address: "liz@example.com", Related: "http://schemas.google.com/g/2005#work"},
+		},
+		PhoneNumber:             []GDPhoneNumber{{DialNumber: "(206)555-1212"}},
+		IM:                      []GDIM{{Address: "liz@gmail.com"}},
+		StructuredPostalAddress: []GDStructuredPostalAddress{{City: "Mountain View"}},
+	}
+	c.ApplyRelDefaults()
+
+	if c.Email[0].Related != relOther || c.Email[0].Label != "" {
+		t.Fatalf("expect default rel on rel-less email, got %+v", c.Email[0])
+	}
+	if c.Email[1].Related != "" || c.Email[1].Label != "Club" {
+		t.Fatalf("labeled email should be unchanged, got %+v", c.Email[1])
+	}
+	if c.Email[2].Related != "http://schemas.google.com/g/2005#work" {
+		t.Fatalf("email with rel should be unchanged, got %+v", c.Email[2])
+	}
+	if c.PhoneNumber[0].Related != relOther || c.IM[0].Related != relOther || c.StructuredPostalAddress[0].Related != relOther {
+		t.Fatalf("expect default rel on phone, im and address")
+	}
+}
+
+func TestValidatePrimary(t *testing.T) {
+	c := ContactKind{
+		Email: []GDEmail{
+			{Address: "liz@gmail.com", Related: EmailRelWork, Primary: true},
+			{Address: "liz@example.org", Related: EmailRelHome, Primary: true},
+		},
+		PhoneNumber: []GDPhoneNumber{
+			{DialNumber: "(206)555-1212", Related: PhoneRelWork, Primary: true},
+			{DialNumber: "(206)555-1213", Related: PhoneRelHome, Primary: true},
+		},
+		IM: []GDIM{{Address: "liz@gmail.com", Related: IMRelHome, Primary: true}},
+	}
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatalf("validate error: expect primary violations")
+	}
+	if s := err.Error(); !strings.Contains(s, "gd:email") || !strings.Contains(s, "gd:phoneNumber") || strings.Contains(s, "gd:im") {
+		t.Fatalf("validate error: expect email and phone violations only, got %v", err)
+	}
+
+	c.Email[1].Primary = false
+	c.PhoneNumber[1].Primary = false
+	if err := c.Validate(); err != nil {
+		t.Fatalf("validate error: one primary per kind should pass, got %v", err)
+	}
+}
+
+func TestNormalizeEmails(t *testing.T) {
+	c := ContactKind{Email: []GDEmail{
+		{Address: " Liz@Example.COM", Related: relOther},
+		{Address: "liz@gmail.com"},
+		{Address: "liz@example.com ", Primary: true},
+	}}
+
+	d := ContactKind{Email: []GDEmail{{Address: "Liz@Example.COM"}, {Address: "liz@example.com"}}}
+	d.NormalizeEmails()
+	if len(d.Email) != 2 || d.Email[0].Address != "Liz@example.com" {
+		t.Fatalf("expect only the domain lowercased, got %v", d.Email)
+	}
+
+	// a copy shares the emails of c, which mustn't be overwritten.
+	cp := c
+	c.NormalizeEmailsIgnoreCase()
+	if cp.Email[0].Address != " Liz@Example.COM" || cp.Email[1].Address != "liz@gmail.com" {
+		t.Fatalf("expect the emails of the copy unchanged, got %v", cp.Email)
+	}
+	if len(c.Email) != 2 {
+		t.Fatalf("expect the mixed-case duplicates collapsed, got %v", c.Email)
+	}
+	if c.Email[0].Address != "liz@example.com" || !c.Email[0].Primary || c.Email[0].Related != relOther {
+		t.Fatalf("expect the first address kept as primary, got %+v", c.Email[0])
+	}
+	if c.Email[1].Address != "liz@gmail.com" || c.Email[1].Primary {
+		t.Fatalf("expect the other address unchanged, got %+v", c.Email[1])
+	}
+}
+
+func TestValidateOrganization(t *testing.T) {
+	c := ContactKind{Organization: []GDOrganization{
+		{Related: "http://schemas.google.com/g/2005#work", Name: "Longbourn"},
+	}}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("validate error: work organization should pass, got %v", err)
+	}
+
+	c.Organization[0].Label = "Estate"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "gd:organization 0") {
+		t.Fatalf("validate error: expect rel and label violation, got %v", err)
+	}
+}
+
+func TestValidateRelLabel(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		contact ContactKind
+		want    string
+	}{
+		{"email both", ContactKind{Email: []GDEmail{{Address: "liz@gmail.com", Related: EmailRelWork, Label: "Estate"}}}, "gd:email 0: both rel and label are set"},
+		{"email neither", ContactKind{Email: []GDEmail{{Address: "liz@gmail.com", Related: EmailRelWork}, {Address: "liz@example.org"}}}, "gd:email 1: neither rel nor label is set"},
+		{"im both", ContactKind{IM: []GDIM{{Address: "liz@gmail.com", Related: IMRelHome, Label: "Chat"}}}, "gd:im 0: both rel and label are set"},
+		{"im neither", ContactKind{IM: []GDIM{{Address: "liz@gmail.com"}}}, "gd:im 0: neither rel nor label is set"},
+		{"phone both", ContactKind{PhoneNumber: []GDPhoneNumber{{DialNumber: "(206)555-1212", Related: PhoneRelMobile, Label: "Desk"}}}, "gd:phoneNumber 0: both rel and label are set"},
+		{"phone neither", ContactKind{PhoneNumber: []GDPhoneNumber{{DialNumber: "(206)555-1212"}}}, "gd:phoneNumber 0: neither rel nor label is set"},
+		{"address both", ContactKind{StructuredPostalAddress: []GDStructuredPostalAddress{{City: "Mountain View", Related: AddressRelWork, Label: "Office"}}}, "gd:structuredPostalAddress 0: both rel and label are set"},
+		{"address neither", ContactKind{StructuredPostalAddress: []GDStructuredPostalAddress{{City: "Mountain View"}}}, "gd:structuredPostalAddress 0: neither rel nor label is set"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.contact.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("validate error: expect %q, got %v", tc.want, err)
+			}
+		})
+	}
+
+	// a label alone is valid.
+	c := ContactKind{Email: []GDEmail{{Address: "liz@gmail.com", Label: "Estate"}}}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("validate error: email with label should pass, got %v", err)
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	c := ContactKind{StructuredPostalAddress: []GDStructuredPostalAddress{
+		{Related: AddressRelWork, City: "Mountain View", MailClass: "http://schemas.google.com/g/2005#letters", Usage: "http://schemas.google.com/g/2005#general", Primary: true},
+		{Related: AddressRelHome, City: "Meryton"},
+	}}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("validate error: documented values should pass, got %v", err)
+	}
+
+	c.StructuredPostalAddress[1].MailClass = "http://schemas.google.com/g/2005#postcards"
+	c.StructuredPostalAddress[1].Usage = "local"
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), `gd:structuredPostalAddress 1: unknown mailClass "http://schemas.google.com/g/2005#postcards"`) ||
+		!strings.Contains(err.Error(), `gd:structuredPostalAddress 1: unknown usage "local"`) {
+		t.Fatalf("validate error: expect the mailClass and usage violations, got %v", err)
+	}
+
+	c.StructuredPostalAddress[1].MailClass, c.StructuredPostalAddress[1].Usage = "", ""
+	c.StructuredPostalAddress[1].Primary = true
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "2 primary gd:structuredPostalAddress") {
+		t.Fatalf("validate error: expect the primary violation, got %v", err)
+	}
+}
+
+func TestValidateIMAddress(t *testing.T) {
+	bs := []byte(testEntry("abc", `"v1"`, `<gd:im rel='http://schemas.google.com/g/2005#home' protocol='http://schemas.google.com/g/2005#GOOGLE_TALK'/>`))
+
+	var c ContactKind
+	if err := xml.Unmarshal(bs, &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	if len(c.IM) != 1 || c.IM[0].Protocol != "http://schemas.google.com/g/2005#GOOGLE_TALK" {
+		t.Fatalf("expect the IM without address kept, got %+v", c.IM)
+	}
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "gd:im 0: no address") {
+		t.Fatalf("validate error: expect the missing IM address, got %v", err)
+	}
+
+	c.IM[0].Address = "liz@gmail.com"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("validate error: IM with address should pass, got %v", err)
+	}
+}
+
+func TestValidateForCreateUpdate(t *testing.T) {
+	c := ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		id:   "http://www.google.com/m8/feeds/contacts/example.com/base/abc",
+	}
+	if err := c.ValidateForCreate(); err == nil || !strings.Contains(err.Error(), "create with id abc") {
+		t.Fatalf("validate error: expect the id rejected on create, got %v", err)
+	}
+	if err := c.ValidateForUpdate(); err == nil {
+		t.Fatalf("validate error: expect error for an update without etag or edit link")
+	}
+
+	c.etag = `"v1"`
+	if err := c.ValidateForUpdate(); err != nil {
+		t.Fatalf("validate error: update with etag should pass, got %v", err)
+	}
+	if err := c.CloneData().ValidateForCreate(); err != nil {
+		t.Fatalf("validate error: create without id should pass, got %v", err)
+	}
+}
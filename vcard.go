@@ -0,0 +1,452 @@
+package contacts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// vcardLineLength is the octet limit of a vCard line before it's folded.
+const vcardLineLength = 75
+
+// vcardTypes maps the rel values, without the namespace, to vCard TYPE tokens.
+var vcardTypes = map[string][]string{
+	"work":     {"WORK"},
+	"home":     {"HOME"},
+	"other":    {"OTHER"},
+	"mobile":   {"CELL"},
+	"main":     {"VOICE"},
+	"pager":    {"PAGER"},
+	"fax":      {"FAX"},
+	"work_fax": {"WORK", "FAX"},
+	"home_fax": {"HOME", "FAX"},
+	"car":      {"CAR"},
+	"isdn":     {"ISDN"},
+}
+
+// vcardIMSchemes maps the IM protocols, without the namespace, to the URI
+// schemes of IMPP. The other protocols are written as X- fields.
+var vcardIMSchemes = map[string]string{
+	"GOOGLE_TALK": "xmpp",
+	"JABBER":      "xmpp",
+	"AIM":         "aim",
+	"MSN":         "msnim",
+	"YAHOO":       "ymsgr",
+	"SKYPE":       "skype",
+	"ICQ":         "icq",
+}
+
+// VCardOption configures MarshalVCard.
+type VCardOption func(*vcardOptions)
+
+type vcardOptions struct {
+	defaultType string
+}
+
+// WithVCardDefaultType sets the TYPE token of the entries that have neither
+// a rel nor a label, "OTHER" by default. An empty t writes them without TYPE.
+func WithVCardDefaultType(t string) VCardOption {
+	return func(o *vcardOptions) {
+		o.defaultType = strings.ToUpper(t)
+	}
+}
+
+// MarshalVCard encodes c as a vCard 3.0 card (RFC 2426).
+// The rel values of emails, phone numbers, addresses and IMs become TYPE
+// parameters, e.g. http://schemas.google.com/g/2005#work becomes WORK,
+// and the primary ones are marked PREF. IMs with a known protocol are
+// written as IMPP, the others as X-<protocol> fields. Without a name,
+// FN is the title of the entry. The entries without rel nor label, which
+// Validate doesn't catch, get the TYPE of WithVCardDefaultType. The
+// birthday is BDAY, --MM-DD without a year.
+func (c ContactKind) MarshalVCard(opts ...VCardOption) ([]byte, error) {
+	o := vcardOptions{defaultType: "OTHER"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	w := &vcardWriter{}
+	w.line("BEGIN", nil, "VCARD")
+	w.line("VERSION", nil, "3.0")
+
+	n := c.Name
+	n.Compose()
+	w.line("N", nil, vcardJoin(";", n.FamilyName, n.GivenName, n.AdditionalName, n.Prefix, n.Suffix))
+	fn := n.FullName
+	if strings.TrimSpace(fn) == "" {
+		// FN is required, the title is the name the server shows.
+		fn = c.GetTitle()
+	}
+	w.line("FN", nil, vcardEscape(fn))
+
+	for _, m := range c.Email {
+		w.line("EMAIL", o.typeParams(m.Related, m.Label, m.Primary, "INTERNET"), vcardEscape(m.Address))
+	}
+	for _, p := range c.PhoneNumber {
+		number := strings.TrimSpace(p.DialNumber)
+		if number == "" {
+			number = p.URI
+		}
+		w.line("TEL", o.typeParams(p.Related, p.Label, p.Primary), vcardEscape(number))
+	}
+	for _, a := range c.StructuredPostalAddress {
+		params := o.typeParams(a.Related, a.Label, a.Primary)
+		w.line("ADR", params, vcardJoin(";", a.Pobox, "", a.Street, a.City, a.Region, a.PostCode, a.Country))
+		if a.FormattedAddress != "" {
+			w.line("LABEL", params, vcardEscape(a.FormattedAddress))
+		}
+	}
+	for _, im := range c.IM {
+		params := o.typeParams(im.Related, im.Label, im.Primary)
+		protocol := im.Protocol[strings.LastIndex(im.Protocol, "#")+1:]
+		if scheme, ok := vcardIMSchemes[protocol]; ok {
+			w.line("IMPP", params, vcardEscape(scheme+":"+im.Address))
+		} else if protocol != "" {
+			w.line("X-"+strings.ReplaceAll(protocol, "_", "-"), params, vcardEscape(im.Address))
+		}
+	}
+	for _, org := range c.Organization {
+		switch {
+		case org.Department != "":
+			w.line("ORG", nil, vcardJoin(";", org.Name, org.Department))
+		case org.Name != "":
+			w.line("ORG", nil, vcardEscape(org.Name))
+		}
+		if org.Title != "" {
+			w.line("TITLE", nil, vcardEscape(org.Title))
+		}
+	}
+	if !c.Birthday.IsZero() && c.Birthday.invalid == "" {
+		w.line("BDAY", nil, c.Birthday.String())
+	}
+	if c.content != "" {
+		w.line("NOTE", nil, vcardEscape(c.content))
+	}
+
+	w.line("END", nil, "VCARD")
+	return w.buf.Bytes(), nil
+}
+
+// parseVCardBirthday parses the date of a BDAY value: the basic or
+// extended forms of ISO 8601, YYYYMMDD or YYYY-MM-DD, or without the year
+// --MMDD or --MM-DD. A time after the date is ignored.
+func parseVCardBirthday(v string) (GContactBirthday, bool) {
+	v, _, _ = strings.Cut(strings.TrimSpace(v), "T")
+	switch {
+	case len(v) == 6 && strings.HasPrefix(v, "--"):
+		v = v[:4] + "-" + v[4:]
+	case len(v) == 8 && !strings.Contains(v, "-"):
+		v = v[:4] + "-" + v[4:6] + "-" + v[6:]
+	}
+	b, err := ParseBirthday(v)
+	return b, err == nil
+}
+
+// vcardWriter writes the content lines of a card, folding the long ones.
+type vcardWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *vcardWriter) line(name string, params []string, value string) {
+	l := name
+	for _, p := range params {
+		l += ";" + p
+	}
+	l += ":" + value
+
+	// fold before the limit, continuing lines with a space, and never in
+	// the middle of a UTF-8 sequence.
+	limit := vcardLineLength
+	for len(l) > limit {
+		i := limit
+		for i > 0 && l[i]&0xC0 == 0x80 {
+			i--
+		}
+		w.buf.WriteString(l[:i])
+		w.buf.WriteString("\r\n ")
+		l = l[i:]
+		limit = vcardLineLength - 1
+	}
+	w.buf.WriteString(l)
+	w.buf.WriteString("\r\n")
+}
+
+// typeParams returns the TYPE parameter of rel, or the default type if
+// there is neither rel nor label, with the extra tokens first and PREF
+// last if primary. It returns nil if there are no tokens.
+func (o vcardOptions) typeParams(rel, label string, primary bool, extra ...string) []string {
+	types := append([]string{}, extra...)
+	switch {
+	case rel != "":
+		types = append(types, vcardTypes[rel[strings.LastIndex(rel, "#")+1:]]...)
+	case label == "" && o.defaultType != "":
+		types = append(types, o.defaultType)
+	}
+	if primary {
+		types = append(types, "PREF")
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	return []string{"TYPE=" + strings.Join(types, ",")}
+}
+
+// vcardJoin escapes the components of a structured value and joins them with sep.
+func vcardJoin(sep string, components ...string) string {
+	for i := range components {
+		components[i] = vcardEscape(components[i])
+	}
+	return strings.Join(components, sep)
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// vcardEscape escapes a text value.
+func vcardEscape(s string) string {
+	return vcardEscaper.Replace(s)
+}
+
+// vcardProperty is a content line of a card.
+type vcardProperty struct {
+	name  string
+	types []string // the TYPE parameter values, upper-cased
+	value string   // the raw value, still escaped
+}
+
+// has reports whether the TYPE parameter of p has t.
+func (p vcardProperty) has(t string) bool {
+	for _, v := range p.types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// rel returns the rel value of the first TYPE token of p that has one
+// among tokens, in their order, or "" if there is none.
+func (p vcardProperty) rel(tokens ...string) string {
+	for _, t := range tokens {
+		if p.has(t) {
+			return nsGD + "#" + strings.ToLower(t)
+		}
+	}
+	return ""
+}
+
+// UnmarshalVCard decodes a vCard 3.0 card into a contact, the inverse of
+// MarshalVCard. N and FN make the name; EMAIL, TEL, ADR, IMPP and the X-
+// IM fields, and ORG and TITLE make the entries, whose rel values come
+// from the TYPE parameter, or "other" without a known type. NOTE is the
+// content, and BDAY the birthday. The other properties, and a BDAY that
+// isn't a date, are kept as extended properties named after the property,
+// with the values of a repeated one joined by newlines.
+func UnmarshalVCard(b []byte) (*ContactKind, error) {
+	props, err := parseVCard(b)
+	if err != nil {
+		return nil, fmt.Errorf("UnmarshalVCard error: %w", err)
+	}
+
+	c := &ContactKind{}
+	for _, p := range props {
+		switch p.name {
+		case "BEGIN", "END", "VERSION", "PRODID":
+		case "N":
+			n := vcardSplit(p.value, 5)
+			c.Name.FamilyName, c.Name.GivenName, c.Name.AdditionalName = n[0], n[1], n[2]
+			c.Name.Prefix, c.Name.Suffix = n[3], n[4]
+		case "FN":
+			c.Name.FullName = vcardUnescape(p.value)
+		case "EMAIL":
+			c.Email = append(c.Email, GDEmail{
+				Address: vcardUnescape(p.value),
+				Related: p.rel("WORK", "HOME"),
+				Primary: p.has("PREF"),
+			})
+		case "TEL":
+			var rel string
+			switch {
+			case p.has("FAX") && p.has("WORK"):
+				rel = nsGD + "#work_fax"
+			case p.has("FAX") && p.has("HOME"):
+				rel = nsGD + "#home_fax"
+			case p.has("CELL"):
+				rel = nsGD + "#mobile"
+			default:
+				rel = p.rel("FAX", "PAGER", "CAR", "ISDN", "WORK", "HOME")
+			}
+			c.PhoneNumber = append(c.PhoneNumber, GDPhoneNumber{
+				DialNumber: vcardUnescape(p.value),
+				Related:    rel,
+				Primary:    p.has("PREF"),
+			})
+		case "ADR":
+			adr := vcardSplit(p.value, 7)
+			street := adr[2]
+			if adr[1] != "" {
+				// gd has no extended address, so it's kept as a street line.
+				street = strings.TrimPrefix(street+"\n"+adr[1], "\n")
+			}
+			c.StructuredPostalAddress = append(c.StructuredPostalAddress, GDStructuredPostalAddress{
+				Related:  p.rel("WORK", "HOME"),
+				Primary:  p.has("PREF"),
+				Pobox:    adr[0],
+				Street:   street,
+				City:     adr[3],
+				Region:   adr[4],
+				PostCode: adr[5],
+				Country:  adr[6],
+			})
+		case "LABEL":
+			if n := len(c.StructuredPostalAddress); n > 0 && c.StructuredPostalAddress[n-1].FormattedAddress == "" {
+				c.StructuredPostalAddress[n-1].FormattedAddress = vcardUnescape(p.value)
+			} else {
+				c.addVCardExtended(p)
+			}
+		case "IMPP":
+			scheme, address, _ := strings.Cut(vcardUnescape(p.value), ":")
+			protocol := ""
+			for k, v := range vcardIMSchemes {
+				// JABBER and GOOGLE_TALK share xmpp; GOOGLE_TALK wins.
+				if v == strings.ToLower(scheme) && (protocol == "" || k == "GOOGLE_TALK") {
+					protocol = k
+				}
+			}
+			if protocol == "" {
+				c.addVCardExtended(p)
+				continue
+			}
+			c.IM = append(c.IM, GDIM{
+				Address:  address,
+				Protocol: nsGD + "#" + protocol,
+				Related:  p.rel("WORK", "HOME"),
+				Primary:  p.has("PREF"),
+			})
+		case "ORG":
+			org := c.vcardOrganization(func(o GDOrganization) bool { return o.Name != "" || o.Department != "" })
+			v := vcardSplit(p.value, 2)
+			org.Name, org.Department = v[0], v[1]
+		case "TITLE":
+			org := c.vcardOrganization(func(o GDOrganization) bool { return o.Title != "" })
+			org.Title = vcardUnescape(p.value)
+		case "NOTE":
+			c.content = vcardUnescape(p.value)
+		case "BDAY":
+			b, ok := parseVCardBirthday(vcardUnescape(p.value))
+			if !ok {
+				c.addVCardExtended(p)
+				continue
+			}
+			c.Birthday = b
+		default:
+			if protocol := strings.ReplaceAll(strings.TrimPrefix(p.name, "X-"), "-", "_"); p.name != protocol && vcardIMProtocols[protocol] {
+				c.IM = append(c.IM, GDIM{
+					Address:  vcardUnescape(p.value),
+					Protocol: nsGD + "#" + protocol,
+					Related:  p.rel("WORK", "HOME"),
+					Primary:  p.has("PREF"),
+				})
+				continue
+			}
+			c.addVCardExtended(p)
+		}
+	}
+	c.ApplyRelDefaults()
+	return c, nil
+}
+
+// vcardIMProtocols are the IM protocols of gd, read from X- fields.
+var vcardIMProtocols = map[string]bool{
+	"AIM": true, "MSN": true, "YAHOO": true, "SKYPE": true, "QQ": true,
+	"GOOGLE_TALK": true, "ICQ": true, "JABBER": true, "NETMEETING": true,
+}
+
+// vcardOrganization returns the organization that ORG and TITLE fill:
+// the last one, or a new one if there is none or the last one is taken.
+func (c *ContactKind) vcardOrganization(taken func(GDOrganization) bool) *GDOrganization {
+	if n := len(c.Organization); n == 0 || taken(c.Organization[n-1]) {
+		c.Organization = append(c.Organization, GDOrganization{Related: nsGD + "#work"})
+	}
+	return &c.Organization[len(c.Organization)-1]
+}
+
+// addVCardExtended keeps an unmapped property as an extended property.
+func (c *ContactKind) addVCardExtended(p vcardProperty) {
+	if c.ExtendedProperty == nil {
+		c.ExtendedProperty = map[string]string{}
+	}
+	v := vcardUnescape(p.value)
+	if old, ok := c.ExtendedProperty[p.name]; ok {
+		v = old + "\n" + v
+	}
+	c.ExtendedProperty[p.name] = v
+}
+
+// parseVCard unfolds the lines of a card and parses its properties.
+func parseVCard(b []byte) ([]vcardProperty, error) {
+	text := strings.ReplaceAll(string(b), "\r\n", "\n")
+	text = strings.NewReplacer("\n ", "", "\n\t", "").Replace(text)
+
+	var props []vcardProperty
+	for _, l := range strings.Split(text, "\n") {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		head, value, ok := strings.Cut(l, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid content line %q", l)
+		}
+		params := strings.Split(head, ";")
+		p := vcardProperty{value: value}
+		// drop the group of the name, e.g. item1.EMAIL.
+		p.name = strings.ToUpper(params[0][strings.LastIndex(params[0], ".")+1:])
+		for _, param := range params[1:] {
+			k, v, ok := strings.Cut(param, "=")
+			switch {
+			case !ok:
+				// vCard 2.1 style, e.g. TEL;WORK:
+				p.types = append(p.types, strings.ToUpper(k))
+			case strings.EqualFold(k, "TYPE"):
+				for _, t := range strings.Split(strings.Trim(v, `"`), ",") {
+					p.types = append(p.types, strings.ToUpper(t))
+				}
+			}
+		}
+		props = append(props, p)
+	}
+	if len(props) == 0 || props[0].name != "BEGIN" || !strings.EqualFold(props[0].value, "VCARD") {
+		return nil, errors.New("not a vCard: no BEGIN:VCARD")
+	}
+	if last := props[len(props)-1]; last.name != "END" || !strings.EqualFold(last.value, "VCARD") {
+		return nil, errors.New("not a vCard: no END:VCARD")
+	}
+	return props, nil
+}
+
+// vcardSplit splits a structured value into n unescaped components,
+// padding the missing ones with "".
+func vcardSplit(value string, n int) []string {
+	ret := make([]string, 0, n)
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ';':
+			ret = append(ret, vcardUnescape(value[start:i]))
+			start = i + 1
+		}
+	}
+	ret = append(ret, vcardUnescape(value[start:]))
+	for len(ret) < n {
+		ret = append(ret, "")
+	}
+	return ret[:n]
+}
+
+var vcardUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// vcardUnescape unescapes a text value.
+func vcardUnescape(s string) string {
+	return vcardUnescaper.Replace(s)
+}
@@ -0,0 +1,229 @@
+package contacts
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContactKindMarshalVCard(t *testing.T) {
+	var c ContactKind
+	if err := xml.Unmarshal([]byte(bennetEntry), &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	c.Organization = []GDOrganization{{Name: "Longbourn, Inc.", Title: "Heiress"}}
+
+	b, err := c.MarshalVCard()
+	if err != nil {
+		t.Fatalf("MarshalVCard error: %v", err)
+	}
+	card := string(b)
+	for _, want := range []string{
+		"BEGIN:VCARD\r\nVERSION:3.0\r\n",
+		"\r\nFN:Elizabeth Bennet\r\n",
+		"\r\nEMAIL;TYPE=INTERNET,WORK,PREF:liz@gmail.com\r\n",
+		"\r\nEMAIL;TYPE=INTERNET,HOME:liz@example.org\r\n",
+		"\r\nTEL;TYPE=WORK,PREF:(206)555-1212\r\n",
+		"\r\nTEL;TYPE=HOME:(206)555-1213\r\n",
+		"\r\nTEL;TYPE=CELL:(206) 555-1212\r\n",
+		"\r\nADR;TYPE=WORK,PREF:;;1600 Amphitheatre Pkwy;Mountain View;CA;94043;\r\n",
+		"\r\nIMPP;TYPE=HOME:xmpp:liz@gmail.com\r\n",
+		"\r\nORG:Longbourn\\, Inc.\r\n",
+		"\r\nTITLE:Heiress\r\n",
+		"\r\nEND:VCARD\r\n",
+	} {
+		if !strings.Contains(card, want) {
+			t.Errorf("expect %q in the card, got\n%s", want, card)
+		}
+	}
+	for _, l := range strings.Split(card, "\r\n") {
+		if len(l) > vcardLineLength {
+			t.Errorf("expect lines folded at %d octets, got %q", vcardLineLength, l)
+		}
+	}
+}
+
+func TestUnmarshalVCard(t *testing.T) {
+	card := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"N:Bennet;Elizabeth;;Miss;\r\n" +
+		"FN:Elizabeth Bennet\r\n" +
+		"EMAIL;TYPE=INTERNET,WORK,PREF:liz@gmail.com\r\n" +
+		"EMAIL;TYPE=internet;TYPE=home:liz@example.org\r\n" +
+		"item1.EMAIL:lizzy@example.com\r\n" +
+		"TEL;TYPE=WORK,VOICE,PREF:(206)555-1212\r\n" +
+		"TEL;TYPE=CELL:(206) 555-1213\r\n" +
+		"TEL;TYPE=WORK,FAX:(206)555-1214\r\n" +
+		"ADR;TYPE=HOME:;Flat 2;800 Main Street;Mountain View;CA;94041;US\r\n" +
+		"ORG:Longbourn\\, Inc.;Estate\r\n" +
+		"TITLE:Heiress\r\n" +
+		"X-SKYPE:lizzy\r\n" +
+		"NOTE:My good friend\\, Liz. A little quick to judge sometimes\\, but nic\r\n" +
+		" e girl.\r\n" +
+		"BDAY:1796-01-28\r\n" +
+		"END:VCARD\r\n"
+
+	c, err := UnmarshalVCard([]byte(card))
+	if err != nil {
+		t.Fatalf("UnmarshalVCard error: %v", err)
+	}
+	if c.Name != (GDName{GivenName: "Elizabeth", FamilyName: "Bennet", Prefix: "Miss", FullName: "Elizabeth Bennet"}) {
+		t.Fatalf("unexpected name %+v", c.Name)
+	}
+
+	emails := []GDEmail{
+		{Address: "liz@gmail.com", Related: "http://schemas.google.com/g/2005#work", Primary: true},
+		{Address: "liz@example.org", Related: "http://schemas.google.com/g/2005#home"},
+		{Address: "lizzy@example.com", Related: relOther},
+	}
+	if len(c.Email) != len(emails) {
+		t.Fatalf("expect %d emails, got %+v", len(emails), c.Email)
+	}
+	for i := range emails {
+		if c.Email[i] != emails[i] {
+			t.Errorf("email %d: expect %+v, got %+v", i, emails[i], c.Email[i])
+		}
+	}
+
+	phones := []GDPhoneNumber{
+		{DialNumber: "(206)555-1212", Related: "http://schemas.google.com/g/2005#work", Primary: true},
+		{DialNumber: "(206) 555-1213", Related: "http://schemas.google.com/g/2005#mobile"},
+		{DialNumber: "(206)555-1214", Related: "http://schemas.google.com/g/2005#work_fax"},
+	}
+	if len(c.PhoneNumber) != len(phones) {
+		t.Fatalf("expect %d phone numbers, got %+v", len(phones), c.PhoneNumber)
+	}
+	for i := range phones {
+		if c.PhoneNumber[i] != phones[i] {
+			t.Errorf("phone number %d: expect %+v, got %+v", i, phones[i], c.PhoneNumber[i])
+		}
+	}
+
+	if len(c.StructuredPostalAddress) != 1 {
+		t.Fatalf("expect 1 address, got %+v", c.StructuredPostalAddress)
+	}
+	if a := c.StructuredPostalAddress[0]; a.Related != "http://schemas.google.com/g/2005#home" ||
+		a.Street != "800 Main Street\nFlat 2" || a.City != "Mountain View" || a.PostCode != "94041" || a.Country != "US" {
+		t.Errorf("unexpected address %+v", a)
+	}
+	if len(c.Organization) != 1 || c.Organization[0].Name != "Longbourn, Inc." ||
+		c.Organization[0].Department != "Estate" || c.Organization[0].Title != "Heiress" {
+		t.Errorf("unexpected organizations %+v", c.Organization)
+	}
+	if len(c.IM) != 1 || c.IM[0].Address != "lizzy" || c.IM[0].Protocol != "http://schemas.google.com/g/2005#SKYPE" {
+		t.Errorf("unexpected IMs %+v", c.IM)
+	}
+	if c.content != "My good friend, Liz. A little quick to judge sometimes, but nice girl." {
+		t.Errorf("unexpected note %q", c.content)
+	}
+	if want := (GContactBirthday{Year: 1796, Month: time.January, Day: 28}); c.Birthday != want {
+		t.Errorf("expect the birthday %+v, got %+v", want, c.Birthday)
+	}
+	if _, ok := c.ExtendedProperty["BDAY"]; ok {
+		t.Errorf("expect BDAY not kept as an extended property, got %v", c.ExtendedProperty)
+	}
+
+	if _, err := UnmarshalVCard([]byte("FN:Elizabeth Bennet\r\n")); err == nil {
+		t.Errorf("expect an error without BEGIN:VCARD")
+	}
+}
+
+func TestVCardRoundTrip(t *testing.T) {
+	var c ContactKind
+	if err := xml.Unmarshal([]byte(bennetEntry), &c); err != nil {
+		t.Fatalf("xml unmarshal error: %v", err)
+	}
+	c.Name = GDName{GivenName: "Elizabeth", FamilyName: "Bennet", FullName: "Elizabeth Bennet"}
+	// the numbers are trimmed in the card.
+	for i := range c.PhoneNumber {
+		c.PhoneNumber[i].DialNumber = strings.TrimSpace(c.PhoneNumber[i].DialNumber)
+	}
+
+	b, err := c.MarshalVCard()
+	if err != nil {
+		t.Fatalf("MarshalVCard error: %v", err)
+	}
+	o, err := UnmarshalVCard(b)
+	if err != nil {
+		t.Fatalf("UnmarshalVCard error: %v", err)
+	}
+	if !o.Equal(c) {
+		t.Fatalf("contacts not match after a round trip:\n%+v\n%+v", c, *o)
+	}
+}
+
+func TestVCardBirthday(t *testing.T) {
+	for _, tc := range []struct {
+		bday string
+		want GContactBirthday
+	}{
+		{"1985-06-01", GContactBirthday{Year: 1985, Month: time.June, Day: 1}},
+		{"19850601", GContactBirthday{Year: 1985, Month: time.June, Day: 1}},
+		{"1985-06-01T00:00:00Z", GContactBirthday{Year: 1985, Month: time.June, Day: 1}},
+		{"--06-01", GContactBirthday{Month: time.June, Day: 1}},
+		{"--0601", GContactBirthday{Month: time.June, Day: 1}},
+	} {
+		c, err := UnmarshalVCard([]byte("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Elizabeth Bennet\r\nBDAY:" + tc.bday + "\r\nEND:VCARD\r\n"))
+		if err != nil {
+			t.Fatalf("UnmarshalVCard error: %v", err)
+		}
+		if c.Birthday != tc.want {
+			t.Errorf("BDAY %s: expect %+v, got %+v", tc.bday, tc.want, c.Birthday)
+		}
+	}
+
+	c, err := UnmarshalVCard([]byte("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Elizabeth Bennet\r\nBDAY:spring\r\nEND:VCARD\r\n"))
+	if err != nil {
+		t.Fatalf("UnmarshalVCard error: %v", err)
+	}
+	if !c.Birthday.IsZero() || c.ExtendedProperty["BDAY"] != "spring" {
+		t.Errorf("expect an invalid BDAY kept as an extended property, got %+v, %v", c.Birthday, c.ExtendedProperty)
+	}
+
+	for _, tc := range []struct {
+		b    GContactBirthday
+		want string
+	}{
+		{GContactBirthday{Year: 1985, Month: time.June, Day: 1}, "\r\nBDAY:1985-06-01\r\n"},
+		{GContactBirthday{Month: time.June, Day: 1}, "\r\nBDAY:--06-01\r\n"},
+	} {
+		b, err := ContactKind{Name: GDName{FullName: "Elizabeth Bennet"}, Birthday: tc.b}.MarshalVCard()
+		if err != nil {
+			t.Fatalf("MarshalVCard error: %v", err)
+		}
+		if !strings.Contains(string(b), tc.want) {
+			t.Errorf("expect %q in the card, got\n%s", tc.want, b)
+		}
+	}
+}
+
+func TestContactKindMarshalVCardDefaultType(t *testing.T) {
+	c := ContactKind{
+		Name: GDName{FullName: "Elizabeth Bennet"},
+		Email: []GDEmail{
+			{Address: "liz@gmail.com"},
+			{Address: "liz@example.org", Label: "Estate"},
+		},
+		PhoneNumber: []GDPhoneNumber{{DialNumber: "(206)555-1212"}},
+	}
+
+	for _, tc := range []struct {
+		opts []VCardOption
+		want []string
+	}{
+		{nil, []string{"\r\nEMAIL;TYPE=INTERNET,OTHER:liz@gmail.com\r\n", "\r\nEMAIL;TYPE=INTERNET:liz@example.org\r\n", "\r\nTEL;TYPE=OTHER:(206)555-1212\r\n"}},
+		{[]VCardOption{WithVCardDefaultType("home")}, []string{"\r\nEMAIL;TYPE=INTERNET,HOME:liz@gmail.com\r\n", "\r\nTEL;TYPE=HOME:(206)555-1212\r\n"}},
+		{[]VCardOption{WithVCardDefaultType("")}, []string{"\r\nEMAIL;TYPE=INTERNET:liz@gmail.com\r\n", "\r\nTEL:(206)555-1212\r\n"}},
+	} {
+		b, err := c.MarshalVCard(tc.opts...)
+		if err != nil {
+			t.Fatalf("MarshalVCard error: %v", err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(string(b), want) {
+				t.Errorf("expect %q in the card, got\n%s", want, b)
+			}
+		}
+	}
+}